@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// actions on the dashboard, which are the names to remap the keys in the config
+const (
+	quitAction         = "quit"
+	helpAction         = "help"
+	upAction           = "up"
+	downAction         = "down"
+	nextModeAction     = "next-mode"
+	previousModeAction = "previous-mode"
+	sortAction         = "sort"
+	reverseSortAction  = "reverse-sort"
+	filterAction       = "filter"
+	detailAction       = "detail"
+	clearAction        = "clear"
+	graphScaleAction   = "graph-scale"
+	groupByAction      = "group-by"
+	pauseAction        = "pause"
+	stepAction         = "step"
+	speedUpAction      = "speed-up"
+	slowDownAction     = "slow-down"
+)
+
+var defaultKeys = map[string][]string{
+	quitAction:         {"q", "<C-c>"},
+	helpAction:         {"?"},
+	upAction:           {"<Up>"},
+	downAction:         {"<Down>"},
+	nextModeAction:     {"<Right>"},
+	previousModeAction: {"<Left>"},
+	sortAction:         {"s"},
+	reverseSortAction:  {"r"},
+	filterAction:       {"/"},
+	detailAction:       {"<Enter>"},
+	clearAction:        {"<Escape>"},
+	graphScaleAction:   {"a"},
+	groupByAction:      {"g"},
+	pauseAction:        {"p"},
+	stepAction:         {"n"},
+	speedUpAction:      {"+"},
+	slowDownAction:     {"-"},
+}
+
+// hintLine describes the actions on a line of the hint.
+type hintLine struct {
+	actions []string
+	text    string
+}
+
+// hint lines in order
+var (
+	// on the top of the dashboard, which is too short for all of them
+	shortHintLines = []hintLine{
+		{[]string{quitAction}, "Quit"},
+		{[]string{helpAction}, "Show All Keys"},
+		{[]string{nextModeAction, previousModeAction}, "Switch Table Mode"},
+		{[]string{detailAction}, "Show Detail"},
+	}
+	hintLines = []hintLine{
+		{[]string{quitAction}, "Quit"},
+		{[]string{helpAction}, "Show/Close All Keys"},
+		{[]string{upAction}, "Up"},
+		{[]string{downAction}, "Down"},
+		{[]string{nextModeAction, previousModeAction}, "Switch Table Mode"},
+		{[]string{sortAction}, "Switch Sort Column"},
+		{[]string{reverseSortAction}, "Reverse Sort Order"},
+		{[]string{filterAction}, "Filter"},
+		{[]string{detailAction}, "Show Detail"},
+		{[]string{clearAction}, "Close Detail, Clear Filter"},
+		{[]string{graphScaleAction}, "Switch Graph Scale"},
+		{[]string{groupByAction}, "Group by Label"},
+	}
+	replayHintLines = []hintLine{
+		{[]string{pauseAction}, "Pause/Resume Replay"},
+		{[]string{stepAction}, "Step Replay"},
+		{[]string{speedUpAction, slowDownAction}, "Change Replay Speed"},
+	}
+)
+
+// keyMap binds the keys to the actions.
+type keyMap struct {
+	keys    map[string][]string
+	actions map[string]string
+}
+
+// newKeyMap replaces the default keys of the actions with the remapped ones.
+func newKeyMap(remap map[string][]string) (*keyMap, error) {
+	keys := make(map[string][]string, len(defaultKeys))
+	for action, ks := range defaultKeys {
+		keys[action] = ks
+	}
+	for action, ks := range remap {
+		if _, ok := defaultKeys[action]; !ok {
+			return nil, errors.Errorf("Unknown action for keys: %v", action)
+		}
+		keys[action] = ks
+	}
+	names := make([]string, 0, len(keys))
+	for action := range keys {
+		names = append(names, action)
+	}
+	sort.Strings(names)
+	actions := make(map[string]string)
+	for _, action := range names {
+		for _, key := range keys[action] {
+			if other, ok := actions[key]; ok {
+				return nil, errors.Errorf("Key %v is bound to both %v and %v", key, other, action)
+			}
+			actions[key] = action
+		}
+	}
+	return &keyMap{
+		keys:    keys,
+		actions: actions,
+	}, nil
+}
+
+// action returns the action bound to the event ID, or empty if none.
+func (k *keyMap) action(id string) string {
+	return k.actions[id]
+}
+
+// shortHint describes the keys to start with.
+func (k *keyMap) shortHint() string {
+	return "\n" + k.describe(shortHintLines)
+}
+
+// hint describes the keys of all actions, including the ones of the replay if replaying.
+func (k *keyMap) hint(replay bool) string {
+	lines := make([]hintLine, 0, len(hintLines)+len(replayHintLines))
+	lines = append(lines, hintLines...)
+	if replay {
+		lines = append(lines, replayHintLines...)
+	}
+	return k.describe(lines)
+}
+
+func (k *keyMap) describe(lines []hintLine) string {
+	text := ""
+	for _, line := range lines {
+		keys := make([]string, 0)
+		for _, action := range line.actions {
+			for _, key := range k.keys[action] {
+				keys = append(keys, describeKey(key))
+			}
+		}
+		text += fmt.Sprintf("%-15v %v\n", strings.Join(keys, ", "), line.text)
+	}
+	return text
+}
+
+// describeKey shows the key as <q> or <Esc>.
+func describeKey(key string) string {
+	if key == "<Escape>" {
+		return "<Esc>"
+	}
+	if strings.HasPrefix(key, "<") {
+		return key
+	}
+	return "<" + key + ">"
+}
@@ -1,22 +1,29 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
 	"regexp"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gizak/termui/v3"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"github.com/ynqa/ktop/pkg/config"
 	"github.com/ynqa/ktop/pkg/ktop"
 	"github.com/ynqa/ktop/pkg/kube"
+	"github.com/ynqa/ktop/pkg/resource"
 	"github.com/ynqa/ktop/pkg/ui"
+	"github.com/ynqa/ktop/pkg/util"
 )
 
 const (
@@ -26,21 +33,51 @@ __  __    ______   ______     ______
 \ \  _"-. \/_/\ \/ \ \ \/\ \  \ \  _-/ 
  \ \_\ \_\   \ \_\  \ \_____\  \ \_\   
   \/_/\/_/    \/_/   \/_____/   \/_/   																			
-`
-	hintStr = `
-<q>, <C-c>      Quit
-<Up>            Up
-<Down>          Down
-<Right>, <Left> Switch Table Mode
 `
 )
 
+const (
+	// the longest delay to retry failed updates
+	maxBackoff = time.Minute
+	// how long to show the errors of the informers
+	informerErrorPeriod = time.Minute
+)
+
+var (
+	// themes for the flag, out of newKtopCmd where ktop is the command
+	defaultTheme = ktop.DarkTheme
+	themeNames   = ktop.ThemeNames
+	// graph scales for the flag
+	defaultGraphScale = ktop.LimitScale
+	graphScales       = ktop.GraphScales
+	defaultGroupBy    = ktop.DefaultGroupBy
+)
+
 type ktopCmd struct {
 	k8sFlags       *genericclioptions.ConfigFlags
 	interval       time.Duration
 	nodeQuery      string
 	podQuery       string
 	containerQuery string
+	scope          kube.Scope
+	metricsOpts    kube.MetricsOptions
+	mode           string
+	output         string
+	count          int
+	record         string
+	replay         string
+	thresholds     resource.Thresholds
+	bell           bool
+	alertCommand   string
+	sort           string
+	reverse        bool
+	configPath     string
+	theme          string
+	graphScale     string
+	graphCPUMax    string
+	graphMemoryMax string
+	groupBy        string
+	units          string
 	renderMutex    sync.RWMutex
 }
 
@@ -79,6 +116,154 @@ func newKtopCmd() *cobra.Command {
 		".*",
 		"container query",
 	)
+	cmd.Flags().BoolVarP(
+		&ktop.scope.AllNamespaces,
+		"all-namespaces",
+		"A",
+		false,
+		"watch pods across all namespaces",
+	)
+	cmd.Flags().StringVarP(
+		&ktop.scope.PodSelector,
+		"selector",
+		"l",
+		"",
+		"label selector for pods",
+	)
+	cmd.Flags().StringVar(
+		&ktop.scope.PodFieldSelector,
+		"field-selector",
+		"",
+		"field selector for pods, e.g. spec.nodeName=node-1,status.phase=Running",
+	)
+	cmd.Flags().StringVar(
+		&ktop.scope.NodeSelector,
+		"node-selector",
+		"",
+		"label selector for nodes",
+	)
+	cmd.Flags().StringVarP(
+		&ktop.mode,
+		"mode",
+		"m",
+		resource.SummarizedType,
+		"table mode, one of: Summarized|All|Node|Workload|Namespace|Group",
+	)
+	cmd.Flags().StringVarP(
+		&ktop.output,
+		"output",
+		"o",
+		"",
+		"print the table instead of the dashboard, one of: table|json|yaml|csv",
+	)
+	cmd.Flags().IntVar(
+		&ktop.count,
+		"count",
+		1,
+		"number of times to print the table with --output, every interval",
+	)
+	cmd.Flags().StringVar(
+		&ktop.metricsOpts.Backend,
+		"metrics-backend",
+		kube.AutoBackend,
+		"backend to get metrics from, one of: "+strings.Join(kube.MetricsBackends, "|"),
+	)
+	cmd.Flags().StringVar(
+		&ktop.metricsOpts.PrometheusURL,
+		"prometheus-url",
+		"",
+		"URL of Prometheus to get metrics from cAdvisor instead of metrics-server",
+	)
+	cmd.Flags().StringVar(
+		&ktop.record,
+		"record",
+		"",
+		"record pods, nodes and their metrics on every interval to the file",
+	)
+	cmd.Flags().StringVar(
+		&ktop.replay,
+		"replay",
+		"",
+		"replay the file recorded with --record instead of watching a cluster",
+	)
+	cmd.Flags().StringVar(
+		&ktop.sort,
+		"sort",
+		"",
+		"column to sort the table by, e.g. CPU(U) or %Memory",
+	)
+	cmd.Flags().BoolVar(
+		&ktop.reverse,
+		"reverse",
+		false,
+		"reverse the sort order",
+	)
+	cmd.Flags().StringVar(
+		&ktop.theme,
+		"theme",
+		defaultTheme,
+		"color theme, one of: "+strings.Join(themeNames, "|")+", monochrome if NO_COLOR is set",
+	)
+	cmd.Flags().StringVar(
+		&ktop.configPath,
+		"config",
+		config.DefaultPath(),
+		"config file for the defaults, which the flags take precedence over",
+	)
+	cmd.Flags().Float64Var(
+		&ktop.thresholds.Warning,
+		"warning",
+		80,
+		"percentage of usage to warn about, of limits for pods and of allocatable for nodes, 0 to disable",
+	)
+	cmd.Flags().Float64Var(
+		&ktop.thresholds.Critical,
+		"critical",
+		90,
+		"percentage of usage to be critical, of limits for pods and of allocatable for nodes, 0 to disable",
+	)
+	cmd.Flags().BoolVar(
+		&ktop.bell,
+		"bell",
+		false,
+		"ring the bell when the rows of the table cross the thresholds",
+	)
+	cmd.Flags().StringVar(
+		&ktop.alertCommand,
+		"alert-command",
+		"",
+		"command to run when the rows of the table cross the thresholds, with KTOP_OBJECT and KTOP_LEVEL in the environment",
+	)
+	cmd.Flags().StringVar(
+		&ktop.graphScale,
+		"graph-scale",
+		defaultGraphScale,
+		"scale of the graphs, one of: "+strings.Join(graphScales, "|")+", where fixed needs --graph-cpu-max and --graph-memory-max",
+	)
+	cmd.Flags().StringVar(
+		&ktop.graphCPUMax,
+		"graph-cpu-max",
+		"",
+		"top of the cpu graphs for pods and containers on the fixed scale, times the pods for the sums of them, e.g. 500m or 2",
+	)
+	cmd.Flags().StringVar(
+		&ktop.graphMemoryMax,
+		"graph-memory-max",
+		"",
+		"top of the memory graphs for pods and containers on the fixed scale, times the pods for the sums of them, e.g. 512Mi or 4Gi",
+	)
+	cmd.Flags().StringVar(
+		&ktop.groupBy,
+		"group-by",
+		defaultGroupBy,
+		"label key to group pods by on Group mode, which is the default mode if given",
+	)
+	cmd.Flags().StringVar(
+		&ktop.units,
+		"units",
+		util.AutoUnits,
+		"units of the resources, one of: "+strings.Join(util.Units, "|")+", where fixed is millicores and MiB, and the default of --output json|yaml|csv",
+	)
 	ktop.k8sFlags = genericclioptions.NewConfigFlags()
 	ktop.k8sFlags.AddFlags(cmd.Flags())
 	if *ktop.k8sFlags.Namespace == "" {
@@ -93,17 +278,118 @@ func (k *ktopCmd) render(items ...termui.Drawable) {
 	termui.Render(items...)
 }
 
+// loadConfig sets the flags to the values in the config, unless they are given on the command line.
+// They are set as the defaults, so that Changed still tells the flags given on the command line.
+func (k *ktopCmd) loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	cfg, err := config.Load(k.configPath, cmd.Flags().Changed("config"))
+	if err != nil {
+		return nil, err
+	}
+	// namespaces on the command line take place of all in the config
+	namespaceChanged := cmd.Flags().Changed("namespace") || cmd.Flags().Changed("all-namespaces")
+	for name, value := range cfg.Flags() {
+		if cmd.Flags().Changed(name) {
+			continue
+		}
+		if namespaceChanged && (name == "namespace" || name == "all-namespaces") {
+			continue
+		}
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			return nil, errors.Errorf("Unknown flag for config: %v", name)
+		}
+		if err := flag.Value.Set(value); err != nil {
+			return nil, errors.Wrapf(err, "Invalid %v in config", name)
+		}
+	}
+	return cfg, nil
+}
+
 func (k *ktopCmd) run(cmd *cobra.Command, args []string) error {
-	if err := termui.Init(); err != nil {
+	cfg, err := k.loadConfig(cmd)
+	if err != nil {
 		return err
 	}
-	defer termui.Close()
+	keys, err := newKeyMap(cfg.Keys)
+	if err != nil {
+		return err
+	}
+	// NO_COLOR is honored unless a theme is given explicitly, see https://no-color.org
+	theme := k.theme
+	if os.Getenv("NO_COLOR") != "" && !cmd.Flags().Changed("theme") {
+		theme = ktop.MonochromeTheme
+	}
+	colors, err := ktop.ParseTheme(theme)
+	if err != nil {
+		return err
+	}
+	for name, value := range cfg.Colors {
+		if err := colors.Set(name, value); err != nil {
+			return err
+		}
+	}
 
-	kubeclients, err := kube.NewKubeClients(k.k8sFlags)
+	// grouping pods is what they want to see unless the mode is given
+	if cmd.Flags().Changed("group-by") && !cmd.Flags().Changed("mode") {
+		k.mode = resource.GroupType
+	}
+	// the outputs to parse are in the fixed units unless the units are given
+	if k.output != "" && k.output != ktop.TableOutput && !cmd.Flags().Changed("units") && cfg.Units == "" {
+		k.units = util.FixedUnits
+	}
+	if err := util.SetUnits(k.units); err != nil {
+		return err
+	}
+	mode, err := resource.ParseTableType(k.mode)
+	if err != nil {
+		return err
+	}
+	sortType := resource.ByName
+	if k.sort != "" {
+		sortType, err = resource.ParseSortType(mode, k.sort)
+		if err != nil {
+			return err
+		}
+	}
+	if err := k.thresholds.Validate(); err != nil {
+		return err
+	}
+	graphRange, err := ktop.ParseGraphRange(k.graphCPUMax, k.graphMemoryMax)
+	if err != nil {
+		return err
+	}
+	graphScale, err := ktop.ParseGraphScale(k.graphScale, graphRange)
 	if err != nil {
 		return err
 	}
 
+	var (
+		kubeclients *kube.KubeClients
+		player      *kube.Player
+	)
+	if k.replay != "" {
+		// the recording has its own namespaces, so show them all by default
+		if !cmd.Flags().Changed("namespace") && len(cfg.Namespaces) == 0 {
+			k.scope.AllNamespaces = true
+		}
+		kubeclients, player, err = kube.NewReplayClients(k.replay, k.k8sFlags, k.scope)
+	} else {
+		kubeclients, err = kube.NewKubeClients(k.k8sFlags, k.scope, k.metricsOpts)
+	}
+	if err != nil {
+		return err
+	}
+	defer kubeclients.Close()
+
+	var recorder *kube.Recorder
+	if k.record != "" {
+		recorder, err = kube.NewRecorder(k.record)
+		if err != nil {
+			return err
+		}
+		defer recorder.Close()
+	}
+
 	// define queries
 	podQuery, err := regexp.Compile(k.podQuery)
 	if err != nil {
@@ -118,19 +404,71 @@ func (k *ktopCmd) run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if k.output != "" {
+		collector := ktop.NewCollector(kubeclients, podQuery, containerQuery, nodeQuery)
+		collector.SetRecorder(recorder)
+		if err := collector.SetGroupBy(k.groupBy); err != nil {
+			return err
+		}
+		return k.print(collector, player, mode, sortType)
+	}
+
+	if err := termui.Init(); err != nil {
+		return err
+	}
+	defer termui.Close()
+
 	monitor := ktop.NewMonitor(kubeclients, podQuery, containerQuery, nodeQuery)
+	monitor.SetRecorder(recorder)
+	if err := monitor.SetGroupBy(k.groupBy); err != nil {
+		return err
+	}
+	monitor.SetThresholds(k.thresholds)
+	if k.bell || k.alertCommand != "" {
+		monitor.SetAlerter(ktop.NewAlerter(os.Stdout, k.bell, k.alertCommand))
+	}
+	monitor.SetColors(colors)
+	monitor.SetTableType(mode)
+	monitor.SetSort(sortType, k.reverse)
+	monitor.SetGraphScale(graphScale, graphRange)
+	monitor.SetGraphInterval(k.interval)
 	logo := ui.NewTextField()
 	logo.Text = logoStr
-	logo.TextStyle = termui.NewStyle(termui.ColorWhite, termui.ColorClear, termui.ModifierBold)
+	logo.TextStyle = termui.NewStyle(colors.Title, termui.ColorClear, termui.ModifierBold)
 	hint := ui.NewTextField()
-	hint.Text = hintStr
-	hint.TextStyle = termui.NewStyle(termui.Color(244), termui.ColorClear)
+	hint.Text = keys.shortHint()
+	hint.TextStyle = termui.NewStyle(colors.Hint, termui.ColorClear)
+	// all keys, drawn over the table while it is open
+	help := ui.NewPane()
+	help.Title = "⎈ Keys, <Esc> to Close ⎈"
+	help.Text = keys.hint(player != nil)
+	help.TitleStyle = termui.NewStyle(colors.Title, termui.ColorClear, termui.ModifierBold)
+	help.BorderStyle = termui.NewStyle(colors.Selected)
+	help.TextStyle = termui.NewStyle(colors.Text)
+	helpOpen := false
+	status := ui.NewTextField()
+	status.TextStyle = termui.NewStyle(colors.Hint, termui.ColorClear)
+
+	// update keeps showing the last good data on transient errors,
+	// and retries them with backoff. Only fatal errors are returned.
+	updater := &updater{
+		monitor: monitor,
+		backoff: ktop.NewBackoff(k.interval, maxBackoff),
+	}
+
+	if player != nil {
+		// show the first frame without waiting for the interval
+		if err := updater.update(); err != nil {
+			return err
+		}
+	}
 
 	grid := termui.NewGrid()
 	grid.Set(
 		termui.NewRow(1./6,
-			termui.NewCol(1./2, logo),
-			termui.NewCol(1./2, hint),
+			termui.NewCol(1./3, logo),
+			termui.NewCol(1./3, hint),
+			termui.NewCol(1./3, status),
 		),
 		termui.NewRow(6./12, monitor.GetPodTable()),
 		termui.NewRow(4./12,
@@ -141,6 +479,22 @@ func (k *ktopCmd) run(cmd *cobra.Command, args []string) error {
 	termWidth, termHeight := termui.TerminalDimensions()
 	grid.SetRect(0, 0, termWidth, termHeight)
 
+	// prompt to edit the query or the label to group by, which is put on the bottom of the table
+	prompt := ui.NewPrompt()
+	prompt.TextStyle = termui.NewStyle(colors.Text)
+	prompt.MessageStyle = termui.NewStyle(colors.Error)
+	prompt.BorderStyle = termui.NewStyle(colors.Selected)
+	// action of the prompt, empty if closed
+	var prompting string
+	// the detail and the help cover the table, and the prompt is on the bottom of it
+	layout := func() {
+		rect := monitor.GetPodTable().GetRect()
+		prompt.SetRect(rect.Min.X, rect.Max.Y-3, rect.Max.X, rect.Max.Y)
+		monitor.GetDetail().SetRect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y)
+		help.SetRect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y)
+	}
+	layout()
+
 	events := termui.PollEvents()
 	tick := time.NewTicker(k.interval)
 	sigCh := make(chan os.Signal, 1)
@@ -151,28 +505,256 @@ func (k *ktopCmd) run(cmd *cobra.Command, args []string) error {
 		case <-sigCh:
 			return nil
 		case <-tick.C:
-			if err := monitor.Update(); err != nil {
+			if player != nil {
+				// update only if the replay has moved to another frame
+				moved, err := player.Advance(k.interval)
+				if err != nil {
+					return err
+				}
+				if !moved {
+					break
+				}
+			}
+			if !updater.backoff.Ready(time.Now()) {
+				break
+			}
+			if err := updater.update(); err != nil {
 				return err
 			}
 		case e := <-events:
-			switch e.ID {
-			case "<Down>":
+			if prompting == groupByAction && e.Type == termui.KeyboardEvent {
+				switch e.ID {
+				case "<Escape>":
+					prompting = ""
+				case "<Enter>":
+					// keep prompting with why the key is invalid
+					if err := monitor.SetGroupBy(prompt.Input); err != nil {
+						prompt.Message = err.Error()
+						break
+					}
+					monitor.SetTableType(resource.GroupType)
+					prompting = ""
+				case "<C-c>":
+					return nil
+				default:
+					editPrompt(prompt, e.ID)
+					prompt.Message = ""
+				}
+				break
+			}
+			if prompting == filterAction && e.Type == termui.KeyboardEvent {
+				switch e.ID {
+				case "<Escape>":
+					monitor.ClearQuery()
+					prompting = ""
+				case "<Enter>":
+					prompting = ""
+				case "<C-c>":
+					return nil
+				default:
+					editPrompt(prompt, e.ID)
+					// apply the query as typing, or show why it is invalid
+					prompt.Message = ""
+					if err := monitor.SetQuery(prompt.Input); err != nil {
+						prompt.Message = err.Error()
+					}
+				}
+				break
+			}
+			// the help takes the keys to scroll and to close it while it is open
+			if helpOpen && e.Type == termui.KeyboardEvent {
+				switch keys.action(e.ID) {
+				case upAction:
+					help.ScrollUp()
+				case downAction:
+					help.ScrollDown()
+				case helpAction, clearAction:
+					helpOpen = false
+				case quitAction:
+					return nil
+				}
+				break
+			}
+			if e.ID == "<Resize>" {
+				termWidth, termHeight := termui.TerminalDimensions()
+				grid.SetRect(0, 0, termWidth, termHeight)
+				layout()
+				break
+			}
+			switch keys.action(e.ID) {
+			case downAction:
 				monitor.ScrollDown()
-			case "<Up>":
+			case upAction:
 				monitor.ScrollUp()
-			case "<Right>":
+			case nextModeAction:
 				monitor.Rotate()
-			case "<Left>":
+			case previousModeAction:
 				monitor.ReverseRotate()
-			case "q", "<C-c>":
+			case sortAction:
+				monitor.Sort()
+			case reverseSortAction:
+				monitor.ReverseSort()
+			case filterAction:
+				target, query := monitor.GetQuery()
+				prompt.Reset(target+" query: ", query)
+				prompting = filterAction
+			case groupByAction:
+				prompt.Reset("group by label: ", monitor.GetGroupBy())
+				prompting = groupByAction
+			case helpAction:
+				help.Offset = 0
+				helpOpen = true
+			case detailAction:
+				monitor.OpenDetail()
+			case clearAction:
+				if monitor.IsDetailOpen() {
+					monitor.CloseDetail()
+				} else {
+					monitor.ClearQuery()
+				}
+			case graphScaleAction:
+				monitor.RotateGraphScale()
+			case pauseAction:
+				if player != nil {
+					player.TogglePause()
+				}
+			case stepAction:
+				if player != nil {
+					moved, err := player.Step()
+					if err != nil {
+						return err
+					}
+					if moved {
+						if err := updater.update(); err != nil {
+							return err
+						}
+					}
+				}
+			case speedUpAction:
+				if player != nil {
+					player.SpeedUp()
+				}
+			case slowDownAction:
+				if player != nil {
+					player.SlowDown()
+				}
+			case quitAction:
 				return nil
-			case "<Resize>":
-				termWidth, termHeight := termui.TerminalDimensions()
-				grid.SetRect(0, 0, termWidth, termHeight)
 			}
 		}
-		k.render(grid)
+		status.Text = statusText(kubeclients, updater, player)
+		items := []termui.Drawable{grid}
+		if monitor.IsDetailOpen() {
+			items = append(items, monitor.GetDetail())
+		}
+		if helpOpen {
+			items = append(items, help)
+		}
+		if prompting != "" {
+			items = append(items, prompt)
+		}
+		k.render(items...)
+	}
+}
+
+// editPrompt edits the input of the prompt by the key.
+func editPrompt(prompt *ui.Prompt, key string) {
+	switch key {
+	case "<Backspace>", "<C-<Backspace>>":
+		prompt.Backspace()
+	case "<Space>":
+		prompt.Insert(" ")
+	default:
+		if utf8.RuneCountInString(key) == 1 {
+			prompt.Insert(key)
+		}
+	}
+}
+
+// updater updates the monitor, and remembers how it went for the status.
+type updater struct {
+	monitor    *ktop.Monitor
+	backoff    *ktop.Backoff
+	lastUpdate time.Time
+	lastErr    error
+}
+
+func (u *updater) update() error {
+	if err := u.monitor.Update(); err != nil {
+		if kube.IsFatal(err) {
+			return err
+		}
+		u.lastErr = err
+		u.backoff.Failed(time.Now())
+		return nil
+	}
+	u.lastUpdate = time.Now()
+	u.lastErr = nil
+	u.backoff.Succeeded()
+	return nil
+}
+
+// statusText describes the health of the metrics backend, how stale the data is,
+// the last error, and the replay if any.
+func statusText(kubeclients *kube.KubeClients, updater *updater, player *kube.Player) string {
+	backend, lastSuccess := kubeclients.GetMetricsHealth()
+	text := fmt.Sprintf("Metrics: %v, Last Success: %v\n", backend, describeTime(lastSuccess))
+	text += fmt.Sprintf("Updated: %v\n", describeTime(updater.lastUpdate))
+	if namespaces := updater.monitor.GetForbiddenNamespaces(); len(namespaces) > 0 {
+		text += fmt.Sprintf("Forbidden: metrics in %v\n", strings.Join(namespaces, ", "))
+	}
+	// the informers retry on their own, so that only the recent error is worth showing
+	if msg, t := kubeclients.GetInformerError(); msg != "" && time.Since(t) < informerErrorPeriod {
+		text += fmt.Sprintf("Watch Error: %v\n", msg)
+	}
+	if updater.lastErr != nil {
+		retry := time.Until(updater.backoff.Next()).Truncate(time.Second)
+		if retry < 0 {
+			retry = 0
+		}
+		text += fmt.Sprintf("Error: %v\nRetry in %v\n", updater.lastErr, retry)
+	}
+	if player != nil {
+		text += player.String()
+	}
+	return text
+}
+
+func describeTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return fmt.Sprintf("%v (%v ago)", t.Format("15:04:05"), time.Since(t).Truncate(time.Second))
+}
+
+// print writes the table for the mode to stdout count times, without the dashboard.
+func (k *ktopCmd) print(collector *ktop.Collector, player *kube.Player, mode string, sortType resource.SortType) error {
+	if k.count < 1 {
+		return errors.Errorf("Invalid count: %v", k.count)
+	}
+	printer, err := ktop.NewPrinter(os.Stdout, k.output)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < k.count; i++ {
+		if i > 0 && player != nil {
+			if _, err := player.Step(); err != nil {
+				return err
+			}
+		} else if i > 0 {
+			time.Sleep(k.interval)
+		}
+		snapshot, err := collector.Collect()
+		if err != nil {
+			return err
+		}
+		viewer := snapshot.Viewer(mode, sortType, k.reverse)
+		viewer.SortRows()
+		if err := printer.Print(viewer); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 func Execute() {
@@ -12,6 +12,7 @@ require (
 	k8s.io/client-go v0.0.0-20190228174230-b40b2a5939e4
 	k8s.io/kubernetes v1.13.4
 	k8s.io/metrics v0.0.0-20190228180609-34472d076c30
+	sigs.k8s.io/yaml v1.1.0
 )
 
 require (
@@ -19,6 +20,7 @@ require (
 	contrib.go.opencensus.io/exporter/ocagent v0.2.0 // indirect
 	github.com/Azure/go-autorest v11.5.2+incompatible // indirect
 	github.com/census-instrumentation/opencensus-proto v0.1.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible // indirect
 	github.com/evanphx/json-patch v4.1.0+incompatible // indirect
 	github.com/gogo/protobuf v1.2.1 // indirect
@@ -54,5 +56,4 @@ require (
 	gopkg.in/inf.v0 v0.9.1 // indirect
 	gopkg.in/yaml.v2 v2.2.2 // indirect
 	k8s.io/klog v0.2.0 // indirect
-	sigs.k8s.io/yaml v1.1.0 // indirect
 )
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// Config holds the defaults of ktop, which the flags take precedence over.
+type Config struct {
+	Interval       string   `json:"interval,omitempty"`
+	Namespaces     []string `json:"namespaces,omitempty"`
+	AllNamespaces  bool     `json:"allNamespaces,omitempty"`
+	PodQuery       string   `json:"podQuery,omitempty"`
+	ContainerQuery string   `json:"containerQuery,omitempty"`
+	NodeQuery      string   `json:"nodeQuery,omitempty"`
+	// label and field selectors for pods, and label selector for nodes
+	Selector      string `json:"selector,omitempty"`
+	FieldSelector string `json:"fieldSelector,omitempty"`
+	NodeSelector  string `json:"nodeSelector,omitempty"`
+	// backend to get metrics from, and the URL of Prometheus for it
+	MetricsBackend string `json:"metricsBackend,omitempty"`
+	PrometheusURL  string `json:"prometheusUrl,omitempty"`
+	// initial table mode
+	Mode string `json:"mode,omitempty"`
+	// column to sort by, and whether to reverse the order
+	Sort    string `json:"sort,omitempty"`
+	Reverse bool   `json:"reverse,omitempty"`
+	// theme to start with, and colors over it by their names, e.g. border: blue
+	Theme  string            `json:"theme,omitempty"`
+	Colors map[string]string `json:"colors,omitempty"`
+	// thresholds in percent, which may be 0 to disable, and how to alert when rows cross them
+	Warning      *float64 `json:"warning,omitempty"`
+	Critical     *float64 `json:"critical,omitempty"`
+	Bell         bool     `json:"bell,omitempty"`
+	AlertCommand string   `json:"alertCommand,omitempty"`
+	// scale of the graphs, and the range for the fixed one, e.g. 2 and 4Gi
+	GraphScale     string `json:"graphScale,omitempty"`
+	GraphCPUMax    string `json:"graphCpuMax,omitempty"`
+	GraphMemoryMax string `json:"graphMemoryMax,omitempty"`
+	// label key to group pods by, e.g. team
+	GroupBy string `json:"groupBy,omitempty"`
+	// units of the resources, e.g. fixed
+	Units string `json:"units,omitempty"`
+	// keys by the actions, e.g. down: ["j", "<Down>"]
+	Keys map[string][]string `json:"keys,omitempty"`
+}
+
+// DefaultPath returns the path of the config, which is
+// $XDG_CONFIG_HOME/ktop/config.yaml or ~/.config/ktop/config.yaml.
+func DefaultPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "ktop", "config.yaml")
+}
+
+// Load reads the config in the path. If the file does not exist,
+// the config is empty unless it must exist.
+func Load(path string, mustExist bool) (*Config, error) {
+	config := &Config{}
+	if path == "" {
+		return config, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !mustExist {
+		return config, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read config")
+	}
+	if err := yaml.UnmarshalStrict(b, config); err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse config %v", path)
+	}
+	return config, nil
+}
+
+// Flags returns the values in the config by the names of the flags for them.
+func (c *Config) Flags() map[string]string {
+	flags := make(map[string]string)
+	set := func(name, value string) {
+		if value != "" {
+			flags[name] = value
+		}
+	}
+	set("interval", c.Interval)
+	set("namespace", strings.Join(c.Namespaces, ","))
+	if c.AllNamespaces {
+		set("all-namespaces", strconv.FormatBool(c.AllNamespaces))
+	}
+	set("pod-query", c.PodQuery)
+	set("container-query", c.ContainerQuery)
+	set("node-query", c.NodeQuery)
+	set("selector", c.Selector)
+	set("field-selector", c.FieldSelector)
+	set("node-selector", c.NodeSelector)
+	set("metrics-backend", c.MetricsBackend)
+	set("prometheus-url", c.PrometheusURL)
+	set("mode", c.Mode)
+	set("sort", c.Sort)
+	set("theme", c.Theme)
+	if c.Warning != nil {
+		set("warning", strconv.FormatFloat(*c.Warning, 'f', -1, 64))
+	}
+	if c.Critical != nil {
+		set("critical", strconv.FormatFloat(*c.Critical, 'f', -1, 64))
+	}
+	if c.Bell {
+		set("bell", strconv.FormatBool(c.Bell))
+	}
+	set("alert-command", c.AlertCommand)
+	set("graph-scale", c.GraphScale)
+	set("graph-cpu-max", c.GraphCPUMax)
+	set("graph-memory-max", c.GraphMemoryMax)
+	set("group-by", c.GroupBy)
+	set("units", c.Units)
+	if c.Reverse {
+		set("reverse", strconv.FormatBool(c.Reverse))
+	}
+	return flags
+}
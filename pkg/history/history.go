@@ -0,0 +1,84 @@
+package history
+
+import "math"
+
+// Store keeps the recent usages of every object, keyed by the object,
+// so that the graphs show their history as soon as they are selected.
+type Store struct {
+	// the number of samples to keep for each object,
+	// and also the number of ticks to keep the objects which disappeared.
+	capacity int
+	tick     int
+	series   map[string]*series
+}
+
+type series struct {
+	cpu      []float64
+	memory   []float64
+	lastTick int
+}
+
+func NewStore(capacity int) *Store {
+	return &Store{
+		capacity: capacity,
+		series:   make(map[string]*series),
+	}
+}
+
+// Add records the usages of the object on the current tick.
+func (s *Store) Add(key string, cpu, memory float64) {
+	v, ok := s.series[key]
+	if !ok {
+		v = &series{}
+		s.series[key] = v
+	}
+	v.cpu = s.trim(append(v.cpu, cpu))
+	v.memory = s.trim(append(v.memory, memory))
+	v.lastTick = s.tick
+}
+
+func (s *Store) trim(data []float64) []float64 {
+	if len(data) > s.capacity {
+		return data[len(data)-s.capacity:]
+	}
+	return data
+}
+
+// Skip moves n ticks on without samples, e.g. of failed updates,
+// which are NaN in the series so that they are gaps on the graphs.
+func (s *Store) Skip(n int) {
+	if n > s.capacity {
+		n = s.capacity
+	}
+	for i := 0; i < n; i++ {
+		for _, v := range s.series {
+			v.cpu = s.trim(append(v.cpu, math.NaN()))
+			v.memory = s.trim(append(v.memory, math.NaN()))
+		}
+		s.Tick()
+	}
+}
+
+// Get returns copies of the usages of the object from the oldest.
+func (s *Store) Get(key string) ([]float64, []float64) {
+	v, ok := s.series[key]
+	if !ok {
+		return make([]float64, 0), make([]float64, 0)
+	}
+	cpu := make([]float64, len(v.cpu))
+	copy(cpu, v.cpu)
+	memory := make([]float64, len(v.memory))
+	copy(memory, v.memory)
+	return cpu, memory
+}
+
+// Tick moves to the next tick, and forgets the objects
+// which have not been recorded for a while.
+func (s *Store) Tick() {
+	s.tick++
+	for key, v := range s.series {
+		if s.tick-v.lastTick > s.capacity {
+			delete(s.series, key)
+		}
+	}
+}
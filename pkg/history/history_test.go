@@ -0,0 +1,83 @@
+package history
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestStoreAdd(t *testing.T) {
+	s := NewStore(3)
+	for i := 1; i <= 4; i++ {
+		s.Add("pod/a", float64(i), float64(i*10))
+		s.Tick()
+	}
+	cpu, memory := s.Get("pod/a")
+	// the oldest is dropped over the capacity
+	if want := []float64{2, 3, 4}; !reflect.DeepEqual(cpu, want) {
+		t.Errorf("got cpu %v, want %v", cpu, want)
+	}
+	if want := []float64{20, 30, 40}; !reflect.DeepEqual(memory, want) {
+		t.Errorf("got memory %v, want %v", memory, want)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	s := NewStore(3)
+	cpu, memory := s.Get("pod/unknown")
+	if cpu == nil || len(cpu) != 0 || memory == nil || len(memory) != 0 {
+		t.Errorf("got %v and %v for an unknown object, want empty ones", cpu, memory)
+	}
+
+	s.Add("pod/a", 1, 10)
+	cpu, _ = s.Get("pod/a")
+	cpu[0] = 100
+	if cpu, _ := s.Get("pod/a"); cpu[0] != 1 {
+		t.Errorf("got %v changed by the copy", cpu[0])
+	}
+}
+
+func TestStoreTick(t *testing.T) {
+	s := NewStore(3)
+	s.Add("pod/a", 1, 10)
+	s.Add("pod/b", 2, 20)
+	s.Tick()
+	// pod/a disappears, and pod/b stays
+	for i := 0; i < 2; i++ {
+		s.Add("pod/b", 2, 20)
+		s.Tick()
+	}
+	if cpu, _ := s.Get("pod/a"); len(cpu) != 1 {
+		t.Errorf("got %v samples of the object kept for the capacity, want 1", len(cpu))
+	}
+	s.Add("pod/b", 2, 20)
+	s.Tick()
+	if cpu, _ := s.Get("pod/a"); len(cpu) != 0 {
+		t.Errorf("got %v samples of the object gone over the capacity, want none", len(cpu))
+	}
+	if cpu, _ := s.Get("pod/b"); len(cpu) != 3 {
+		t.Errorf("got %v samples of the object staying, want 3", len(cpu))
+	}
+}
+
+func TestStoreSkip(t *testing.T) {
+	s := NewStore(3)
+	s.Add("pod/a", 1, 10)
+	s.Tick()
+	s.Skip(1)
+	s.Add("pod/a", 2, 20)
+	s.Tick()
+	cpu, memory := s.Get("pod/a")
+	if len(cpu) != 3 || cpu[0] != 1 || !math.IsNaN(cpu[1]) || cpu[2] != 2 {
+		t.Errorf("got cpu %v, want a gap between 1 and 2", cpu)
+	}
+	if len(memory) != 3 || !math.IsNaN(memory[1]) {
+		t.Errorf("got memory %v, want a gap", memory)
+	}
+
+	// the objects are forgotten over the capacity even without samples
+	s.Skip(10)
+	if cpu, _ := s.Get("pod/a"); len(cpu) != 0 {
+		t.Errorf("got %v samples skipped over the capacity, want none", len(cpu))
+	}
+}
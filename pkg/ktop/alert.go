@@ -0,0 +1,69 @@
+package ktop
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/ynqa/ktop/pkg/resource"
+)
+
+// Alerter fires when objects cross the thresholds upward,
+// by ringing the bell and/or running the command.
+type Alerter struct {
+	bell    bool
+	command string
+	w       io.Writer
+
+	// levels of the objects last time
+	levels map[string]resource.Level
+}
+
+func NewAlerter(w io.Writer, bell bool, command string) *Alerter {
+	return &Alerter{
+		bell:    bell,
+		command: command,
+		w:       w,
+	}
+}
+
+// Observe updates the levels of the objects, and fires for the ones
+// whose level got higher since the last observation. Objects not observed are forgotten,
+// and new ones only take their levels, since they have not crossed anything yet.
+func (a *Alerter) Observe(levels map[string]resource.Level) {
+	fired := false
+	for key, level := range levels {
+		if last, ok := a.levels[key]; ok && level > last {
+			fired = true
+			a.run(key, level)
+		}
+	}
+	a.levels = levels
+	if fired && a.bell {
+		fmt.Fprint(a.w, "\a")
+	}
+}
+
+// Reset forgets the levels, so that the objects of the next observation are new,
+// e.g. of the objects on another table.
+func (a *Alerter) Reset() {
+	a.levels = nil
+}
+
+// run runs the command in background with the object and its level in the environment,
+// e.g. KTOP_OBJECT=pod/default/nginx KTOP_LEVEL=critical.
+func (a *Alerter) run(key string, level resource.Level) {
+	if a.command == "" {
+		return
+	}
+	cmd := exec.Command("sh", "-c", a.command)
+	cmd.Env = append(os.Environ(),
+		"KTOP_OBJECT="+key,
+		"KTOP_LEVEL="+level.String(),
+	)
+	if err := cmd.Start(); err != nil {
+		return
+	}
+	go cmd.Wait()
+}
@@ -0,0 +1,109 @@
+package ktop
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ynqa/ktop/pkg/resource"
+)
+
+func TestAlerterObserve(t *testing.T) {
+	tests := []struct {
+		name         string
+		observations []map[string]resource.Level
+		// bells rung on each observation
+		bells []int
+	}{
+		{
+			name: "first observation",
+			observations: []map[string]resource.Level{
+				{"pod/a": resource.Critical, "pod/b": resource.Warning},
+			},
+			bells: []int{0},
+		},
+		{
+			name: "upward",
+			observations: []map[string]resource.Level{
+				{"pod/a": resource.Normal},
+				{"pod/a": resource.Warning},
+				{"pod/a": resource.Critical},
+			},
+			bells: []int{0, 1, 1},
+		},
+		{
+			name: "staying",
+			observations: []map[string]resource.Level{
+				{"pod/a": resource.Normal},
+				{"pod/a": resource.Critical},
+				{"pod/a": resource.Critical},
+			},
+			bells: []int{0, 1, 0},
+		},
+		{
+			name: "downward and upward again",
+			observations: []map[string]resource.Level{
+				{"pod/a": resource.Critical},
+				{"pod/a": resource.Warning},
+				{"pod/a": resource.Normal},
+				{"pod/a": resource.Warning},
+			},
+			bells: []int{0, 0, 0, 1},
+		},
+		{
+			name: "once for many objects",
+			observations: []map[string]resource.Level{
+				{"pod/a": resource.Normal, "pod/b": resource.Normal},
+				{"pod/a": resource.Warning, "pod/b": resource.Critical},
+			},
+			bells: []int{0, 1},
+		},
+		{
+			name: "new object",
+			observations: []map[string]resource.Level{
+				{"pod/a": resource.Normal},
+				{"pod/a": resource.Normal, "pod/b": resource.Warning},
+				{"pod/a": resource.Normal, "pod/b": resource.Critical},
+			},
+			bells: []int{0, 0, 1},
+		},
+		{
+			name: "forgotten object",
+			observations: []map[string]resource.Level{
+				{"pod/a": resource.Normal},
+				{},
+				{"pod/a": resource.Critical},
+				{"pod/a": resource.Critical},
+			},
+			bells: []int{0, 0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			alerter := NewAlerter(&buf, true, "")
+			for i, levels := range tt.observations {
+				buf.Reset()
+				alerter.Observe(levels)
+				if got := buf.Len(); got != tt.bells[i] {
+					t.Errorf("observation %v: got %v bells, want %v", i, got, tt.bells[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAlerterReset(t *testing.T) {
+	var buf bytes.Buffer
+	alerter := NewAlerter(&buf, true, "")
+	alerter.Observe(map[string]resource.Level{"pod/a": resource.Normal})
+	alerter.Reset()
+	alerter.Observe(map[string]resource.Level{"node/a": resource.Critical})
+	if buf.Len() != 0 {
+		t.Error("got a bell on the first observation after the reset")
+	}
+	alerter.Observe(map[string]resource.Level{"node/a": resource.Normal})
+	alerter.Observe(map[string]resource.Level{"node/a": resource.Critical})
+	if buf.Len() != 1 {
+		t.Error("got no bell on crossing after the reset")
+	}
+}
@@ -0,0 +1,48 @@
+package ktop
+
+import (
+	"time"
+)
+
+// Backoff spaces out retries of failed updates exponentially,
+// so that a struggling API server is not hammered on every tick.
+type Backoff struct {
+	initial time.Duration
+	max     time.Duration
+	current time.Duration
+	next    time.Time
+}
+
+func NewBackoff(initial, max time.Duration) *Backoff {
+	return &Backoff{
+		initial: initial,
+		max:     max,
+	}
+}
+
+// Ready reports whether it is time to try again.
+func (b *Backoff) Ready(now time.Time) bool {
+	return !now.Before(b.next)
+}
+
+// Failed doubles the delay until the next try, up to the max.
+func (b *Backoff) Failed(now time.Time) {
+	if b.current == 0 {
+		b.current = b.initial
+	} else if b.current*2 <= b.max {
+		b.current *= 2
+	} else {
+		b.current = b.max
+	}
+	b.next = now.Add(b.current)
+}
+
+func (b *Backoff) Succeeded() {
+	b.current = 0
+	b.next = time.Time{}
+}
+
+// Next returns when to try again.
+func (b *Backoff) Next() time.Time {
+	return b.next
+}
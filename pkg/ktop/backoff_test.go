@@ -0,0 +1,39 @@
+package ktop
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	start := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	b := NewBackoff(time.Second, 5*time.Second)
+	if !b.Ready(start) {
+		t.Error("got not ready before failures")
+	}
+
+	// delays after each failure at the start
+	for i, want := range []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second,
+	} {
+		b.Failed(start)
+		if got := b.Next().Sub(start); got != want {
+			t.Errorf("failure %v: got delay %v, want %v", i, got, want)
+		}
+		if b.Ready(start.Add(want - time.Millisecond)) {
+			t.Errorf("failure %v: got ready before the delay", i)
+		}
+		if !b.Ready(start.Add(want)) {
+			t.Errorf("failure %v: got not ready after the delay", i)
+		}
+	}
+
+	b.Succeeded()
+	if !b.Ready(start) {
+		t.Error("got not ready after success")
+	}
+	b.Failed(start)
+	if got := b.Next().Sub(start); got != time.Second {
+		t.Errorf("got delay %v after success, want the initial", got)
+	}
+}
@@ -0,0 +1,367 @@
+package ktop
+
+import (
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/metrics/pkg/apis/metrics"
+
+	"github.com/ynqa/ktop/pkg/kube"
+	"github.com/ynqa/ktop/pkg/resource"
+	. "github.com/ynqa/ktop/pkg/util"
+)
+
+// DefaultGroupBy is the label recommended for the names of applications.
+const DefaultGroupBy = "app.kubernetes.io/name"
+
+// Collector fetches pods, nodes and their metrics,
+// and joins them into resources to show.
+type Collector struct {
+	*kube.KubeClients
+
+	podQuery       *regexp.Regexp
+	containerQuery *regexp.Regexp
+	nodeQuery      *regexp.Regexp
+	// workloads and namespaces are filtered on the dashboard only
+	workloadQuery  *regexp.Regexp
+	namespaceQuery *regexp.Regexp
+	groupQuery     *regexp.Regexp
+
+	// label key to group pods by
+	groupBy string
+	// namespaces which metrics are not allowed to get in on the last Collect
+	forbiddenNamespaces []string
+
+	recorder *kube.Recorder
+}
+
+func NewCollector(kubeclients *kube.KubeClients, podQuery, containerQuery, nodeQuery *regexp.Regexp) *Collector {
+	return &Collector{
+		KubeClients:    kubeclients,
+		podQuery:       podQuery,
+		containerQuery: containerQuery,
+		nodeQuery:      nodeQuery,
+		workloadQuery:  regexp.MustCompile(".*"),
+		namespaceQuery: regexp.MustCompile(".*"),
+		groupQuery:     regexp.MustCompile(".*"),
+		groupBy:        DefaultGroupBy,
+	}
+}
+
+// SetGroupBy groups pods by the label of the key from the next Collect.
+func (c *Collector) SetGroupBy(key string) error {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return errors.Errorf("Invalid label key %q: %v", key, errs[0])
+	}
+	c.groupBy = key
+	return nil
+}
+
+func (c *Collector) GetGroupBy() string {
+	return c.groupBy
+}
+
+// GetForbiddenNamespaces returns the namespaces whose pods are left out on the last Collect,
+// since their metrics are not allowed to get.
+func (c *Collector) GetForbiddenNamespaces() []string {
+	return c.forbiddenNamespaces
+}
+
+// Snapshot holds the resources collected at once.
+type Snapshot struct {
+	time                time.Time
+	nodeList            *corev1.NodeList
+	resources           []*resource.Resource
+	summarizedResources []*resource.SummarizedResource
+	nodeResources       []*resource.NodeResource
+	workloadResources   []*resource.WorkloadResource
+	namespaceResources  []*resource.NamespaceResource
+	groupResources      []*resource.GroupResource
+	// label key which the groups are by
+	groupBy string
+}
+
+// Viewer returns the viewer of the resources for the table type.
+func (s *Snapshot) Viewer(typ string, sortType resource.SortType, reverse bool) resource.ResourceTableViewer {
+	switch typ {
+	case resource.AllType:
+		return resource.AsAllTableViewer(s.resources, sortType, reverse)
+	case resource.NodeType:
+		return resource.AsNodeTableViewer(s.nodeResources, sortType, reverse)
+	case resource.WorkloadType:
+		return resource.AsWorkloadTableViewer(s.workloadResources, sortType, reverse)
+	case resource.NamespaceType:
+		return resource.AsNamespaceTableViewer(s.namespaceResources, sortType, reverse)
+	case resource.GroupType:
+		return resource.AsGroupTableViewer(s.groupResources, s.groupBy, sortType, reverse)
+	default:
+		return resource.AsSummarizedTableViewer(s.summarizedResources, sortType, reverse)
+	}
+}
+
+// Collect fetches pods, nodes and their metrics, and joins them.
+func (c *Collector) Collect() (*Snapshot, error) {
+	frame, err := c.fetch()
+	if err != nil {
+		return nil, err
+	}
+	if c.recorder != nil {
+		if err := c.recorder.Record(frame); err != nil {
+			return nil, err
+		}
+	}
+	resources, summarizedResources := c.joinPodResources(frame)
+	return &Snapshot{
+		time:                frame.Time,
+		nodeList:            frame.NodeList,
+		resources:           resources,
+		summarizedResources: summarizedResources,
+		nodeResources:       c.joinNodeResources(frame),
+		workloadResources:   c.joinWorkloadResources(frame, summarizedResources),
+		namespaceResources:  c.joinNamespaceResources(frame, summarizedResources),
+		groupResources:      c.joinGroupResources(summarizedResources),
+		groupBy:             c.groupBy,
+	}, nil
+}
+
+// SetRecorder makes Collect record the objects fetched.
+func (c *Collector) SetRecorder(recorder *kube.Recorder) {
+	c.recorder = recorder
+}
+
+// fetch gets the objects to join as they are.
+func (c *Collector) fetch() (*kube.Frame, error) {
+	now := c.Now()
+	nodeList, err := c.GetNodeList(c.NodeSelector)
+	if err != nil {
+		return nil, err
+	}
+	nodePodList, nodePodsKnown, err := c.GetNodePodList()
+	if err != nil {
+		return nil, err
+	}
+	replicaSetList, err := c.GetReplicaSetList()
+	if err != nil {
+		return nil, err
+	}
+	resourceQuotaList, err := c.GetResourceQuotaList()
+	if err != nil {
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, 2)
+	podListCh := make(chan *corev1.PodList, 1)
+	podMetricsListCh := make(chan *metrics.PodMetricsList, 1)
+	forbiddenCh := make(chan []string, 1)
+	nodeMetricsListCh := make(chan *metrics.NodeMetricsList, 1)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		podList, podMetricsList, forbidden, err := c.fetchPods()
+		if err != nil {
+			errCh <- err
+			return
+		}
+		podListCh <- podList
+		podMetricsListCh <- podMetricsList
+		forbiddenCh <- forbidden
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		nodeMetricsList, err := c.GetNodeMetricsList(c.NodeSelector)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		nodeMetricsListCh <- nodeMetricsList
+	}()
+
+	go func() {
+		wg.Wait()
+		close(errCh)
+		close(podListCh)
+		close(podMetricsListCh)
+		close(forbiddenCh)
+		close(nodeMetricsListCh)
+	}()
+
+	// merged errors are caused by a fatal one if any, see kube.IsFatal
+	var mergedError error
+	for err := range errCh {
+		switch {
+		case mergedError == nil:
+			mergedError = err
+		case kube.IsFatal(err) && !kube.IsFatal(mergedError):
+			mergedError = errors.Wrap(err, mergedError.Error())
+		default:
+			mergedError = errors.Wrap(mergedError, err.Error())
+		}
+	}
+	if mergedError != nil {
+		return nil, mergedError
+	}
+
+	podList, ok := <-podListCh
+	if !ok {
+		return nil, errors.New("Failed to get pods")
+	}
+
+	podMetricsList, ok := <-podMetricsListCh
+	if !ok {
+		return nil, errors.New("Failed to get pod metrics")
+	}
+
+	nodeMetricsList, ok := <-nodeMetricsListCh
+	if !ok {
+		return nil, errors.New("Failed to get node metrics")
+	}
+	c.forbiddenNamespaces = <-forbiddenCh
+
+	return &kube.Frame{
+		Time:              now,
+		NodeList:          nodeList,
+		PodList:           podList,
+		NodePodList:       nodePodList,
+		NodePodsUnknown:   !nodePodsKnown,
+		ReplicaSetList:    replicaSetList,
+		ResourceQuotaList: resourceQuotaList,
+		NodeMetricsList:   nodeMetricsList,
+		PodMetricsList:    podMetricsList,
+	}, nil
+}
+
+// fetchPods gets pods and their metrics in all namespaces to watch.
+// The namespaces which metrics are not allowed to get in are left out,
+// unless they are all, and returned.
+func (c *Collector) fetchPods() (*corev1.PodList, *metrics.PodMetricsList, []string, error) {
+	podList := &corev1.PodList{}
+	podMetricsList := &metrics.PodMetricsList{}
+	forbidden := make([]string, 0)
+	for _, namespace := range c.Namespaces {
+		podMetrics, err := c.GetPodMetricsList(namespace, c.PodSelector)
+		if kube.IsForbidden(err) && len(forbidden)+1 < len(c.Namespaces) {
+			forbidden = append(forbidden, namespace)
+			continue
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		// pods out of the field selector are not in the list,
+		// so that their metrics are dropped on joining.
+		pods, err := c.GetPodList(namespace, c.PodSelector)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		podMetricsList.Items = append(podMetricsList.Items, podMetrics.Items...)
+		podList.Items = append(podList.Items, pods.Items...)
+	}
+	return podList, podMetricsList, forbidden, nil
+}
+
+func (c *Collector) joinPodResources(frame *kube.Frame) ([]*resource.Resource, []*resource.SummarizedResource) {
+	// collect resource list
+	resources := make([]*resource.Resource, 0)
+	summarizedResources := make([]*resource.SummarizedResource, 0)
+	// filtered
+	for _, podMetrics := range FilterPodMetrics(c.podQuery, frame.PodMetricsList.Items) {
+		pod := FindPod(podMetrics.Namespace, podMetrics.Name, frame.PodList.Items)
+		if pod == nil {
+			continue
+		}
+		detail := resource.NewPod(*pod, podMetrics)
+		containerResources := make([]*resource.Resource, 0)
+		// filtered
+		for _, containerMetrics := range FilterContainerMetrics(c.containerQuery, podMetrics.Containers) {
+			container := FindContainer(containerMetrics.Name, pod.Spec.Containers)
+			if container == nil {
+				continue
+			}
+			containerResources = append(containerResources, resource.NewResource(detail, *container, containerMetrics))
+		}
+		resources = append(resources, containerResources...)
+		summarizedResource := resource.NewSummarizedResource(detail, containerResources)
+		summarizedResources = append(summarizedResources, summarizedResource)
+	}
+	return resources, summarizedResources
+}
+
+func (c *Collector) joinNodeResources(frame *kube.Frame) []*resource.NodeResource {
+	// all pods on the nodes regardless of the query and the scope, with the usage unknown if no metrics
+	nodePodList := frame.NodePodList
+	if nodePodList == nil {
+		nodePodList = frame.PodList
+	}
+	podMetricsByName := make(map[string]metrics.PodMetrics)
+	for _, pm := range frame.PodMetricsList.Items {
+		podMetricsByName[pm.Namespace+"/"+pm.Name] = pm
+	}
+	podsByNode := make(map[string][]*resource.Pod)
+	// the pods in the scope are not all on the nodes
+	if frame.NodePodsUnknown {
+		nodePodList = &corev1.PodList{}
+	}
+	for _, pod := range nodePodList.Items {
+		// finished pods hold no resources on the node
+		if pod.Spec.NodeName == "" ||
+			pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		podMetrics := podMetricsByName[pod.Namespace+"/"+pod.Name]
+		podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], resource.NewPod(pod, podMetrics))
+	}
+
+	resources := make([]*resource.NodeResource, 0)
+	// filtered
+	for _, nodeMetrics := range FilterNodeMetrics(c.nodeQuery, frame.NodeMetricsList.Items) {
+		node := FindNode(nodeMetrics.Name, frame.NodeList.Items)
+		if node == nil {
+			continue
+		}
+		resources = append(resources, resource.NewNodeResource(*node, nodeMetrics, podsByNode[node.Name], !frame.NodePodsUnknown))
+	}
+	return resources
+}
+
+// joinWorkloadResources groups the pods on the table by their controllers.
+func (c *Collector) joinWorkloadResources(frame *kube.Frame, summarizedResources []*resource.SummarizedResource) []*resource.WorkloadResource {
+	resources := make([]*resource.WorkloadResource, 0)
+	// filtered
+	for _, r := range resource.NewWorkloadResources(summarizedResources, frame.ReplicaSetList) {
+		if c.workloadQuery.MatchString(r.GetWorkloadName()) {
+			resources = append(resources, r)
+		}
+	}
+	return resources
+}
+
+// joinNamespaceResources sums up the pods on the table by their namespaces.
+func (c *Collector) joinNamespaceResources(frame *kube.Frame, summarizedResources []*resource.SummarizedResource) []*resource.NamespaceResource {
+	resources := make([]*resource.NamespaceResource, 0)
+	// filtered
+	for _, r := range resource.NewNamespaceResources(summarizedResources, frame.ResourceQuotaList) {
+		if c.namespaceQuery.MatchString(r.GetNamespace()) {
+			resources = append(resources, r)
+		}
+	}
+	return resources
+}
+
+// joinGroupResources sums up the pods on the table by the values of the label.
+func (c *Collector) joinGroupResources(summarizedResources []*resource.SummarizedResource) []*resource.GroupResource {
+	resources := make([]*resource.GroupResource, 0)
+	// filtered
+	for _, r := range resource.NewGroupResources(summarizedResources, c.groupBy) {
+		if c.groupQuery.MatchString(r.GetValue()) {
+			resources = append(resources, r)
+		}
+	}
+	return resources
+}
@@ -0,0 +1,182 @@
+package ktop
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gizak/termui/v3"
+	"github.com/pkg/errors"
+)
+
+// Colors are the colors of the dashboard.
+type Colors struct {
+	Border           termui.Color
+	Selected         termui.Color
+	Title            termui.Color
+	Text             termui.Color
+	Hint             termui.Color
+	Error            termui.Color
+	GraphLabelName   termui.Color
+	GraphLimit       termui.Color
+	GraphRequest     termui.Color
+	GraphAllocatable termui.Color
+	GraphQuota       termui.Color
+	GraphData        termui.Color
+	Warning          termui.Color
+	Critical         termui.Color
+
+	// modifiers of the cells over the thresholds,
+	// which tell them apart without colors
+	WarningModifier  termui.Modifier
+	CriticalModifier termui.Modifier
+}
+
+const (
+	// theme names
+	DarkTheme         = "dark"
+	LightTheme        = "light"
+	HighContrastTheme = "high-contrast"
+	MonochromeTheme   = "monochrome"
+)
+
+var (
+	DefaultColors = Themes[DarkTheme]
+
+	ThemeNames = []string{DarkTheme, LightTheme, HighContrastTheme, MonochromeTheme}
+	Themes     = map[string]Colors{
+		DarkTheme: {
+			Border:           termui.ColorBlue,
+			Selected:         termui.ColorYellow,
+			Title:            termui.ColorWhite,
+			Text:             termui.ColorWhite,
+			Hint:             termui.Color(244),
+			Error:            termui.ColorRed,
+			GraphLabelName:   termui.ColorWhite,
+			GraphLimit:       termui.ColorWhite,
+			GraphRequest:     termui.ColorCyan,
+			GraphAllocatable: termui.ColorMagenta,
+			GraphQuota:       termui.ColorRed,
+			GraphData:        termui.ColorGreen,
+			Warning:          termui.ColorYellow,
+			Critical:         termui.ColorRed,
+		},
+		// for terminals with the light background
+		LightTheme: {
+			Border:           termui.ColorBlue,
+			Selected:         termui.ColorBlue,
+			Title:            termui.ColorBlack,
+			Text:             termui.ColorBlack,
+			Hint:             termui.Color(240),
+			Error:            termui.ColorRed,
+			GraphLabelName:   termui.ColorBlack,
+			GraphLimit:       termui.ColorBlack,
+			GraphRequest:     termui.Color(31),
+			GraphAllocatable: termui.Color(90),
+			GraphQuota:       termui.Color(124),
+			GraphData:        termui.Color(28),
+			Warning:          termui.Color(130),
+			Critical:         termui.ColorRed,
+		},
+		HighContrastTheme: {
+			Border:           termui.Color(15),
+			Selected:         termui.Color(11),
+			Title:            termui.Color(15),
+			Text:             termui.Color(15),
+			Hint:             termui.Color(15),
+			Error:            termui.Color(9),
+			GraphLabelName:   termui.Color(15),
+			GraphLimit:       termui.Color(15),
+			GraphRequest:     termui.Color(14),
+			GraphAllocatable: termui.Color(13),
+			GraphQuota:       termui.Color(9),
+			GraphData:        termui.Color(10),
+			Warning:          termui.Color(11),
+			Critical:         termui.Color(9),
+			WarningModifier:  termui.ModifierBold,
+			CriticalModifier: termui.ModifierBold,
+		},
+		// the default colors of the terminal only, e.g. for NO_COLOR
+		MonochromeTheme: {
+			Border:           termui.ColorClear,
+			Selected:         termui.ColorClear,
+			Title:            termui.ColorClear,
+			Text:             termui.ColorClear,
+			Hint:             termui.ColorClear,
+			Error:            termui.ColorClear,
+			GraphLabelName:   termui.ColorClear,
+			GraphLimit:       termui.ColorClear,
+			GraphRequest:     termui.ColorClear,
+			GraphAllocatable: termui.ColorClear,
+			GraphQuota:       termui.ColorClear,
+			GraphData:        termui.ColorClear,
+			Warning:          termui.ColorClear,
+			Critical:         termui.ColorClear,
+			WarningModifier:  termui.ModifierUnderline,
+			CriticalModifier: termui.ModifierBold | termui.ModifierUnderline,
+		},
+	}
+)
+
+// ParseTheme returns the colors of the theme by its name.
+func ParseTheme(name string) (Colors, error) {
+	colors, ok := Themes[strings.ToLower(name)]
+	if !ok {
+		return Colors{}, errors.Errorf("Unknown theme: %v", name)
+	}
+	return colors, nil
+}
+
+// Set sets the color by its name in the config, e.g. border or graphData,
+// to a color name like blue or a 256-color number.
+func (c *Colors) Set(name, value string) error {
+	color, err := ParseColor(value)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "border":
+		c.Border = color
+	case "selected":
+		c.Selected = color
+	case "title":
+		c.Title = color
+	case "text":
+		c.Text = color
+	case "hint":
+		c.Hint = color
+	case "error":
+		c.Error = color
+	case "graphLabelName":
+		c.GraphLabelName = color
+	case "graphLimit":
+		c.GraphLimit = color
+	case "graphRequest":
+		c.GraphRequest = color
+	case "graphAllocatable":
+		c.GraphAllocatable = color
+	case "graphQuota":
+		c.GraphQuota = color
+	case "graphData":
+		c.GraphData = color
+	case "warning":
+		c.Warning = color
+	case "critical":
+		c.Critical = color
+	default:
+		return errors.Errorf("Unknown color: %v", name)
+	}
+	return nil
+}
+
+// ParseColor parses a color name like red, or a number of 256 colors.
+func ParseColor(s string) (termui.Color, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if color, ok := termui.StyleParserColorMap[s]; ok {
+		return color, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < -1 || n > 255 {
+		return 0, errors.Errorf("Invalid color: %v", s)
+	}
+	return termui.Color(n), nil
+}
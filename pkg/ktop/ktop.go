@@ -3,94 +3,119 @@ package ktop
 import (
 	"container/ring"
 	"fmt"
+	"math"
 	"regexp"
-	"sync"
+	"strconv"
+	"time"
 
 	"github.com/gizak/termui/v3"
-	"github.com/pkg/errors"
 
 	corev1 "k8s.io/api/core/v1"
-	kr "k8s.io/apimachinery/pkg/api/resource"
-	"k8s.io/apimachinery/pkg/labels"
 
+	"github.com/ynqa/ktop/pkg/history"
 	"github.com/ynqa/ktop/pkg/kube"
 	"github.com/ynqa/ktop/pkg/resource"
 	"github.com/ynqa/ktop/pkg/ui"
 	. "github.com/ynqa/ktop/pkg/util"
 )
 
-var (
-	// style
-	titleStyle = termui.NewStyle(termui.ColorWhite, termui.ColorClear, termui.ModifierBold)
-)
-
 const (
 	// label names
-	containerLimitLabel  = "ContainerLimits"
+	requestLabel         = "Requests"
+	limitLabel           = "Limits"
 	nodeAllocatableLabel = "NodeAllocatable"
+	requestQuotaLabel    = "RequestsQuota"
+	limitQuotaLabel      = "LimitsQuota"
 
-	// colors
-	borderColor         = termui.ColorBlue
-	selectedTableColor  = termui.ColorYellow
-	graphLabelNameColor = termui.ColorWhite
-	graphLimitColor     = termui.ColorWhite
-	graphDataColor      = termui.ColorGreen
+	// the number of samples kept for each object,
+	// which is enough to fill the graphs on wide terminals.
+	historySize = 600
 )
 
 type Monitor struct {
-	*kube.KubeClients
+	*Collector
 
 	table           *ui.Table
 	tableTypeCircle *ring.Ring
 
 	cpuGraph *ui.Graph
 	memGraph *ui.Graph
-
-	podQuery       *regexp.Regexp
-	containerQuery *regexp.Regexp
-	nodeQuery      *regexp.Regexp
+	// scale of the graphs, and the range for the fixed one
+	graphScaleCircle *ring.Ring
+	graphRange       GraphRange
+
+	// detail of the selected row, drawn over the table while it is open
+	detail     *ui.Pane
+	detailOpen bool
+
+	sortType    resource.SortType
+	reverseSort bool
+
+	// the latest resources fetched by Update
+	snapshot *Snapshot
+	history  *history.Store
+	// time of the last samples in the history
+	lastSampleTime time.Time
+
+	thresholds resource.Thresholds
+	alerter    *Alerter
+	colors     Colors
 }
 
 func NewMonitor(kubeclients *kube.KubeClients, podQuery, containerQuery, nodeQuery *regexp.Regexp) *Monitor {
 	monitor := &Monitor{
-		KubeClients:     kubeclients,
-		tableTypeCircle: resource.TableTypeCircle(),
-		podQuery:        podQuery,
-		containerQuery:  containerQuery,
-		nodeQuery:       nodeQuery,
+		Collector:        NewCollector(kubeclients, podQuery, containerQuery, nodeQuery),
+		tableTypeCircle:  resource.TableTypeCircle(),
+		graphScaleCircle: graphScaleCircle(LimitScale, GraphRange{}),
+		history:          history.NewStore(historySize),
 	}
 
 	// table for resources
 	table := ui.NewTable()
-	table.TitleStyle = titleStyle
 	table.Cursor = true
-	table.BorderStyle = termui.NewStyle(borderColor)
-	table.CursorColor = selectedTableColor
 
 	// graph for cpu
 	cpu := ui.NewGraph()
 	cpu.Title = "⎈ CPU Usage ⎈"
-	cpu.TitleStyle = titleStyle
-	cpu.BorderStyle = termui.NewStyle(borderColor)
-	cpu.LabelNameColor = graphLabelNameColor
-	cpu.DataColor = graphDataColor
-	cpu.LimitColor = graphLimitColor
 
 	// graph for memory
 	mem := ui.NewGraph()
 	mem.Title = "⎈ Memory Usage ⎈"
-	mem.TitleStyle = titleStyle
-	mem.BorderStyle = termui.NewStyle(borderColor)
-	mem.LabelNameColor = graphLabelNameColor
-	mem.DataColor = graphDataColor
-	mem.LimitColor = graphLimitColor
+
+	// detail for the selected row
+	detail := ui.NewPane()
+	detail.Text = "No data points"
 
 	monitor.table = table
 	monitor.cpuGraph = cpu
 	monitor.memGraph = mem
+	monitor.detail = detail
+	monitor.SetColors(DefaultColors)
 	return monitor
 }
 
+// SetColors paints the widgets with the colors.
+func (m *Monitor) SetColors(colors Colors) {
+	m.colors = colors
+	titleStyle := termui.NewStyle(colors.Title, termui.ColorClear, termui.ModifierBold)
+
+	m.table.TitleStyle = titleStyle
+	m.table.BorderStyle = termui.NewStyle(colors.Border)
+	m.table.CursorColor = colors.Selected
+	m.table.TextColor = colors.Text
+
+	for _, graph := range []*ui.Graph{m.cpuGraph, m.memGraph} {
+		graph.TitleStyle = titleStyle
+		graph.BorderStyle = termui.NewStyle(colors.Border)
+		graph.LabelNameColor = colors.GraphLabelName
+		graph.AxisColor = colors.Border
+	}
+
+	m.detail.TitleStyle = titleStyle
+	m.detail.BorderStyle = termui.NewStyle(colors.Selected)
+	m.detail.TextStyle = termui.NewStyle(colors.Text)
+}
+
 func (m *Monitor) resetGraph() {
 	m.cpuGraph.Reset()
 	m.memGraph.Reset()
@@ -100,41 +125,198 @@ func (m *Monitor) resetTable() {
 	m.table.Reset(resource.ResetTableShapeFrom(
 		m.tableTypeCircle.Value.(string),
 		m.table.Inner,
+		m.sortType,
+		m.reverseSort,
 	))
 }
 
 func (m *Monitor) ScrollDown() {
 	m.scrollDown()
-	m.resetGraph()
+	m.refresh()
 }
 
 func (m *Monitor) scrollDown() {
+	if m.detailOpen {
+		m.detail.ScrollDown()
+		return
+	}
 	m.table.ScrollDown()
 }
 
 func (m *Monitor) ScrollUp() {
 	m.scrollUp()
-	m.resetGraph()
+	m.refresh()
 }
 
 func (m *Monitor) scrollUp() {
+	if m.detailOpen {
+		m.detail.ScrollUp()
+		return
+	}
 	m.table.ScrollUp()
 }
 
 func (m *Monitor) Rotate() {
 	m.rotate(1)
-	m.resetGraph()
 	m.resetTable()
+	m.refresh()
 }
 
 func (m *Monitor) ReverseRotate() {
 	m.rotate(-1)
-	m.resetGraph()
 	m.resetTable()
+	m.refresh()
+}
+
+// SetTableType switches the table to the type.
+func (m *Monitor) SetTableType(typ string) {
+	for i := 0; i < m.tableTypeCircle.Len(); i++ {
+		if m.tableTypeCircle.Value.(string) == typ {
+			break
+		}
+		m.rotate(1)
+	}
+	m.resetTable()
+	m.refresh()
 }
 
 func (m *Monitor) rotate(i int) {
 	m.tableTypeCircle = m.tableTypeCircle.Move(i)
+	m.detailOpen = false
+	m.resetAlerter()
+	if !resource.IsSortable(m.tableTypeCircle.Value.(string), m.sortType) {
+		m.sortType = resource.ByName
+		m.reverseSort = false
+	}
+}
+
+// Sort switches the sort column to the next one.
+func (m *Monitor) Sort() {
+	m.sortType = resource.NextSortType(m.tableTypeCircle.Value.(string), m.sortType)
+	m.resort()
+}
+
+// SetSort sorts the rows by the sort type in the order.
+func (m *Monitor) SetSort(sortType resource.SortType, reverse bool) {
+	m.sortType = sortType
+	m.reverseSort = reverse
+	m.resort()
+}
+
+// ReverseSort reverses the order of rows.
+func (m *Monitor) ReverseSort() {
+	m.reverseSort = !m.reverseSort
+	m.resort()
+}
+
+func (m *Monitor) resort() {
+	if m.snapshot == nil {
+		m.resetTable()
+	}
+	m.refresh()
+}
+
+// queryFor returns the query for the current table type:
+// pods on Summarized, containers on All, nodes on Node, workloads on Workload,
+// namespaces on Namespace and the values of the label on Group.
+func (m *Monitor) queryFor() (string, **regexp.Regexp) {
+	switch m.tableTypeCircle.Value.(string) {
+	case resource.AllType:
+		return "container", &m.containerQuery
+	case resource.NodeType:
+		return "node", &m.nodeQuery
+	case resource.WorkloadType:
+		return "workload", &m.workloadQuery
+	case resource.NamespaceType:
+		return "namespace", &m.namespaceQuery
+	case resource.GroupType:
+		return "group", &m.groupQuery
+	default:
+		return "pod", &m.podQuery
+	}
+}
+
+// GetQuery returns the target and the expression of the query for the current table type.
+func (m *Monitor) GetQuery() (string, string) {
+	target, query := m.queryFor()
+	return target, (*query).String()
+}
+
+// SetQuery replaces the query for the current table type,
+// which is applied on the next Update.
+func (m *Monitor) SetQuery(expr string) error {
+	compiled, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	_, query := m.queryFor()
+	if (*query).String() != compiled.String() {
+		*query = compiled
+		m.table.SelectedRow = 0
+		m.resetGraph()
+		m.resetAlerter()
+	}
+	return nil
+}
+
+// ClearQuery matches all objects for the current table type.
+func (m *Monitor) ClearQuery() {
+	m.SetQuery(".*")
+}
+
+// SetGroupBy regroups the latest pods by the label of the key.
+func (m *Monitor) SetGroupBy(key string) error {
+	if key == m.groupBy {
+		return nil
+	}
+	if err := m.Collector.SetGroupBy(key); err != nil {
+		return err
+	}
+	if m.snapshot != nil {
+		m.snapshot.groupResources = m.joinGroupResources(m.snapshot.summarizedResources)
+		m.snapshot.groupBy = key
+	}
+	m.table.SelectedRow = 0
+	m.resetGraph()
+	m.resetAlerter()
+	m.refresh()
+	return nil
+}
+
+// SetThresholds colors the rows and the cells over the thresholds.
+func (m *Monitor) SetThresholds(thresholds resource.Thresholds) {
+	m.thresholds = thresholds
+}
+
+// SetAlerter makes Update alert when objects cross the thresholds.
+func (m *Monitor) SetAlerter(alerter *Alerter) {
+	m.alerter = alerter
+}
+
+// resetAlerter makes the alerter take the rows as they are on the next Update,
+// since the ones got on the table by the user have not crossed the thresholds.
+func (m *Monitor) resetAlerter() {
+	if m.alerter != nil {
+		m.alerter.Reset()
+	}
+}
+
+// SetGraphScale scales the graphs, with the range for the fixed scale.
+func (m *Monitor) SetGraphScale(scale string, r GraphRange) {
+	m.graphScaleCircle = graphScaleCircle(scale, r)
+	m.graphRange = r
+}
+
+// SetGraphInterval puts the time markers on the graphs, a sample per interval.
+func (m *Monitor) SetGraphInterval(interval time.Duration) {
+	m.cpuGraph.Interval = interval
+	m.memGraph.Interval = interval
+}
+
+// RotateGraphScale switches the graphs to the next scale.
+func (m *Monitor) RotateGraphScale() {
+	m.graphScaleCircle = m.graphScaleCircle.Next()
+	m.refresh()
 }
 
 func (m *Monitor) GetCPUGraph() *ui.Graph {
@@ -149,257 +331,522 @@ func (m *Monitor) GetPodTable() *ui.Table {
 	return m.table
 }
 
+// GetDetail returns the detail of the selected row,
+// which is laid out over the table by the caller.
+func (m *Monitor) GetDetail() *ui.Pane {
+	return m.detail
+}
+
+// OpenDetail shows the detail of the selected row.
+func (m *Monitor) OpenDetail() {
+	m.detailOpen = true
+	m.detail.Offset = 0
+	m.refresh()
+}
+
+// CloseDetail goes back to the table.
+func (m *Monitor) CloseDetail() {
+	m.detailOpen = false
+}
+
+// IsDetailOpen reports whether the detail is shown.
+func (m *Monitor) IsDetailOpen() bool {
+	return m.detailOpen
+}
+
 func (m *Monitor) Update() error {
-	nodeList, err := m.GetNodeList(labels.Everything())
+	snapshot, err := m.Collect()
 	if err != nil {
-		return nil
+		return err
 	}
+	m.snapshot = snapshot
+	m.recordHistory()
+	m.alert()
+	m.refresh()
+	return nil
+}
 
-	var wg sync.WaitGroup
-	errCh := make(chan error, 2)
-	resourcesCh := make(chan []*resource.Resource, 1)
-	summarizedResourcesCh := make(chan []*resource.SummarizedResource, 1)
-	nodeResourcesCh := make(chan []*resource.NodeResource, 1)
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		resources, summarizedResources, err := m.fetchPodResources()
-		if err != nil {
-			errCh <- err
-			return
+// alert checks the objects of the table, even the ones scrolled out.
+// The other tables are left, since they would alert again for the pods
+// in their containers, workloads, namespaces and groups.
+func (m *Monitor) alert() {
+	if m.alerter == nil {
+		return
+	}
+	levels := make(map[string]resource.Level)
+	switch m.tableTypeCircle.Value.(string) {
+	case resource.AllType:
+		for _, r := range m.snapshot.resources {
+			levels[r.Key()] = r.Level(m.thresholds)
+		}
+	case resource.NodeType:
+		for _, r := range m.snapshot.nodeResources {
+			levels[r.Key()] = r.Level(m.thresholds)
 		}
-		resourcesCh <- resources
-		summarizedResourcesCh <- summarizedResources
-	}()
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		nodeResources, err := m.fetchNodeResources(nodeList)
-		if err != nil {
-			errCh <- err
-			return
+	case resource.WorkloadType:
+		for _, r := range m.snapshot.workloadResources {
+			levels[r.Key()] = r.Level(m.thresholds)
 		}
-		nodeResourcesCh <- nodeResources
-	}()
-
-	go func() {
-		wg.Wait()
-		close(errCh)
-		close(resourcesCh)
-		close(summarizedResourcesCh)
-		close(nodeResourcesCh)
-	}()
-
-	var mergedError error
-	for err := range errCh {
-		if mergedError == nil {
-			mergedError = errors.New(err.Error())
+	case resource.NamespaceType:
+		for _, r := range m.snapshot.namespaceResources {
+			levels[r.Key()] = r.Level(m.thresholds)
+		}
+	case resource.GroupType:
+		for _, r := range m.snapshot.groupResources {
+			levels[r.Key()] = r.Level(m.thresholds)
+		}
+	default:
+		for _, r := range m.snapshot.summarizedResources {
+			levels[r.Key()] = r.Level(m.thresholds)
 		}
-		mergedError = errors.Wrap(mergedError, err.Error())
-	}
-	if mergedError != nil {
-		return mergedError
 	}
+	m.alerter.Observe(levels)
+}
 
-	resources, ok := <-resourcesCh
-	if !ok {
-		return errors.New("Failed to get resources")
+// recordHistory adds the usages of all objects, not only the selected one,
+// so that their graphs are ready when they are selected.
+func (m *Monitor) recordHistory() {
+	// ticks missed since the last samples, e.g. on failed updates, are gaps,
+	// so that the time markers on the graphs stay right
+	if interval := m.cpuGraph.Interval; interval > 0 && !m.lastSampleTime.IsZero() {
+		ticks := int((m.snapshot.time.Sub(m.lastSampleTime) + interval/2) / interval)
+		m.history.Skip(ticks - 1)
+	}
+	m.lastSampleTime = m.snapshot.time
+	for _, r := range m.snapshot.resources {
+		cpu, _ := r.GetCpuUsage()
+		mem, _ := r.GetMemoryUsage()
+		m.history.Add(r.Key(), cpu, mem)
+	}
+	for _, r := range m.snapshot.summarizedResources {
+		cpu, _ := r.GetCpuUsage()
+		mem, _ := r.GetMemoryUsage()
+		m.history.Add(r.Key(), cpu, mem)
+	}
+	for _, r := range m.snapshot.nodeResources {
+		cpu, _ := r.GetCpuUsagePercentage()
+		mem, _ := r.GetMemoryUsagePercentage()
+		m.history.Add(r.Key(), cpu, mem)
+	}
+	for _, r := range m.snapshot.workloadResources {
+		cpu, _ := r.GetCpuUsage()
+		mem, _ := r.GetMemoryUsage()
+		m.history.Add(r.Key(), cpu, mem)
+	}
+	for _, r := range m.snapshot.namespaceResources {
+		cpu, _ := r.GetCpuUsage()
+		mem, _ := r.GetMemoryUsage()
+		m.history.Add(r.Key(), cpu, mem)
 	}
+	for _, r := range m.snapshot.groupResources {
+		cpu, _ := r.GetCpuUsage()
+		mem, _ := r.GetMemoryUsage()
+		m.history.Add(r.Key(), cpu, mem)
+	}
+	m.history.Tick()
+}
 
-	summarizedResources, ok := <-summarizedResourcesCh
-	if !ok {
-		return errors.New("Failed to get summarized resources")
+// refresh shows the latest resources on the table and the graphs
+// for the current table type and row, without fetching them.
+func (m *Monitor) refresh() {
+	if m.snapshot == nil {
+		m.resetGraph()
+		return
 	}
 
-	nodeResources, ok := <-nodeResourcesCh
-	if !ok {
-		return errors.New("Failed to get node resources")
+	m.updateTable()
+	if err := m.updateGraph(); err != nil {
+		m.resetGraph()
+	}
+	if m.detailOpen {
+		m.updateDetail()
 	}
+}
 
-	// temporary
-	defer func() {
-		if p := recover(); p != nil {
-			m.table.SelectedRow = 0
+// updateDetail describes the pod or the node of the selected row.
+func (m *Monitor) updateDetail() {
+	var describer interface {
+		Describe(now time.Time) string
+	}
+	switch m.tableTypeCircle.Value.(string) {
+	case resource.SummarizedType:
+		m.detail.Title = "⎈ Pod Detail, <Esc> to Close ⎈"
+		if len(m.snapshot.summarizedResources) > 0 {
+			describer = m.snapshot.summarizedResources[m.table.SelectedRow].GetPod()
+		}
+	case resource.AllType:
+		m.detail.Title = "⎈ Pod Detail, <Esc> to Close ⎈"
+		if len(m.snapshot.resources) > 0 {
+			describer = m.snapshot.resources[m.table.SelectedRow].GetPod()
+		}
+	case resource.NodeType:
+		m.detail.Title = "⎈ Node Detail, <Esc> to Close ⎈"
+		if len(m.snapshot.nodeResources) > 0 {
+			describer = m.snapshot.nodeResources[m.table.SelectedRow]
+		}
+	case resource.WorkloadType:
+		m.detail.Title = "⎈ Workload Detail, <Esc> to Close ⎈"
+		if len(m.snapshot.workloadResources) > 0 {
+			describer = m.snapshot.workloadResources[m.table.SelectedRow]
+		}
+	case resource.NamespaceType:
+		m.detail.Title = "⎈ Namespace Detail, <Esc> to Close ⎈"
+		if len(m.snapshot.namespaceResources) > 0 {
+			describer = m.snapshot.namespaceResources[m.table.SelectedRow]
 		}
-	}()
+	case resource.GroupType:
+		m.detail.Title = "⎈ Group Detail, <Esc> to Close ⎈"
+		if len(m.snapshot.groupResources) > 0 {
+			describer = m.snapshot.groupResources[m.table.SelectedRow]
+		}
+	}
+	if describer == nil {
+		m.detail.Text = "No data points"
+		return
+	}
+	// ages are as of the fetch, which is the time recorded on replaying
+	m.detail.Text = describer.Describe(m.snapshot.time)
+}
+
+// updateTable sorts the latest resources for the current table type,
+// and shows them on the table.
+func (m *Monitor) updateTable() {
+	viewer := m.snapshot.Viewer(m.tableTypeCircle.Value.(string), m.sortType, m.reverseSort)
+	viewer.SortRows()
+	m.updatePodTable(viewer)
+}
 
+// updateGraph shows the history of the selected row on the graphs.
+func (m *Monitor) updateGraph() error {
 	switch m.tableTypeCircle.Value.(string) {
 	case resource.SummarizedType:
-		summarizedViewer := resource.AsSummarizedTableViewer(summarizedResources, resource.ByName)
-		summarizedViewer.SortRows()
-		m.updatePodTable(summarizedViewer)
-		if len(summarizedResources) > 0 {
-			current := summarizedResources[m.table.SelectedRow]
-			if err := m.updateSummarizedGraph(nodeList, current); err != nil {
+		if len(m.snapshot.summarizedResources) > 0 {
+			current := m.snapshot.summarizedResources[m.table.SelectedRow]
+			if err := m.updateSummarizedGraph(m.snapshot.nodeList, current); err != nil {
 				return err
 			}
 		}
 	case resource.AllType:
-		viewer := resource.AsAllTableViewer(resources, resource.ByName)
-		viewer.SortRows()
-		m.updatePodTable(viewer)
-		if len(resources) > 0 {
-			current := resources[m.table.SelectedRow]
-			if err := m.updateAllGraph(nodeList, current); err != nil {
+		if len(m.snapshot.resources) > 0 {
+			current := m.snapshot.resources[m.table.SelectedRow]
+			if err := m.updateAllGraph(m.snapshot.nodeList, current); err != nil {
 				return err
 			}
 		}
 	case resource.NodeType:
-		nodeViewer := resource.AsNodeTableViewer(nodeResources, resource.ByName)
-		nodeViewer.SortRows()
-		m.updatePodTable(nodeViewer)
-		if len(nodeResources) > 0 {
-			current := nodeResources[m.table.SelectedRow]
+		if len(m.snapshot.nodeResources) > 0 {
+			current := m.snapshot.nodeResources[m.table.SelectedRow]
 			if err := m.updateNodeGraph(current); err != nil {
 				return err
 			}
 		}
+	case resource.WorkloadType:
+		if len(m.snapshot.workloadResources) > 0 {
+			current := m.snapshot.workloadResources[m.table.SelectedRow]
+			if err := m.updateWorkloadGraph(current); err != nil {
+				return err
+			}
+		}
+	case resource.NamespaceType:
+		if len(m.snapshot.namespaceResources) > 0 {
+			current := m.snapshot.namespaceResources[m.table.SelectedRow]
+			if err := m.updateNamespaceGraph(current); err != nil {
+				return err
+			}
+		}
+	case resource.GroupType:
+		if len(m.snapshot.groupResources) > 0 {
+			current := m.snapshot.groupResources[m.table.SelectedRow]
+			if err := m.updateGroupGraph(current); err != nil {
+				return err
+			}
+		}
 	default:
 	}
-
 	return nil
 }
 
-func (m *Monitor) fetchPodResources() ([]*resource.Resource, []*resource.SummarizedResource, error) {
-	podMetricsList, err := m.GetPodMetricsList(*m.Flags.Namespace, labels.Everything())
-	if err != nil {
-		return nil, nil, err
-	}
-	podList, err := m.GetPodList(*m.Flags.Namespace, labels.Everything())
-	if err != nil {
-		return nil, nil, err
+func (m *Monitor) updatePodTable(resources resource.ResourceTableViewer) {
+	m.table.Title, m.table.Header, m.table.ColumnWidths, m.table.Rows = resources.GetTableShape(m.table.Inner)
+	// the selected row may be gone, e.g. the pod was deleted
+	if m.table.SelectedRow >= len(m.table.Rows) {
+		m.table.SelectedRow = IntMax(len(m.table.Rows)-1, 0)
 	}
 
-	// collect resource list
-	resources := make([]*resource.Resource, 0)
-	summarizedResources := make([]*resource.SummarizedResource, 0)
-	// filtered
-	for _, podMetrics := range FilterPodMetrics(m.podQuery, podMetricsList.Items) {
-		podName := podMetrics.Name
-		pod := FindPod(podName, podList.Items)
-		if pod == nil {
-			continue
-		}
-		var cpu, mem kr.Quantity
-		// filtered
-		for _, containerMetrics := range FilterContainerMetrics(m.containerQuery, podMetrics.Containers) {
-			container := FindContainer(containerMetrics.Name, pod.Spec.Containers)
-			if container == nil {
-				continue
+	levels := resources.GetLevels(m.thresholds)
+	m.table.RowColors = make([]termui.Color, len(levels))
+	m.table.CellColors = make([][]termui.Color, len(levels))
+	m.table.CellModifiers = make([][]termui.Modifier, len(levels))
+	for i, row := range levels {
+		var max resource.Level
+		m.table.CellColors[i] = make([]termui.Color, len(row))
+		m.table.CellModifiers[i] = make([]termui.Modifier, len(row))
+		for j, level := range row {
+			m.table.CellColors[i][j] = m.levelColor(level)
+			m.table.CellModifiers[i][j] = m.levelModifier(level)
+			if level > max {
+				max = level
 			}
-			containerResource := resource.NewResource(*pod, *container, containerMetrics)
-			resources = append(resources, containerResource)
-			cpu.Add(*containerMetrics.Usage.Cpu())
-			mem.Add(*containerMetrics.Usage.Memory())
 		}
-		summarizedResource := resource.NewSummarizedResource(*pod,
-			corev1.ResourceList{
-				corev1.ResourceCPU:    cpu,
-				corev1.ResourceMemory: mem,
-			})
-		summarizedResources = append(summarizedResources, summarizedResource)
+		m.table.RowColors[i] = m.levelColor(max)
 	}
-	return resources, summarizedResources, nil
 }
 
-func (m *Monitor) fetchNodeResources(nodeList *corev1.NodeList) ([]*resource.NodeResource, error) {
-	nodeMetricsList, err := m.GetNodeMetricsList(labels.Everything())
-	if err != nil {
-		return nil, err
+func (m *Monitor) levelModifier(level resource.Level) termui.Modifier {
+	switch level {
+	case resource.Warning:
+		return m.colors.WarningModifier
+	case resource.Critical:
+		return m.colors.CriticalModifier
+	default:
+		return termui.ModifierClear
 	}
-	resources := make([]*resource.NodeResource, 0)
-	// filtered
-	for _, nodeMetrics := range FilterNodeMetrics(m.nodeQuery, nodeMetricsList.Items) {
-		node := FindNode(nodeMetrics.Name, nodeList.Items)
-		resources = append(resources, resource.NewNodeResource(*node, nodeMetrics))
+}
+
+func (m *Monitor) levelColor(level resource.Level) termui.Color {
+	switch level {
+	case resource.Warning:
+		return m.colors.Warning
+	case resource.Critical:
+		return m.colors.Critical
+	default:
+		return termui.ColorClear
 	}
-	return resources, nil
 }
 
-func (m *Monitor) updatePodTable(resources resource.ResourceTableViewer) {
-	m.table.Title, m.table.Header, m.table.ColumnWidths, m.table.Rows = resources.GetTableShape(m.table.Inner)
+// formats of the values on the graphs, in the units of the tables
+func formatCPU(v float64) string {
+	return FormatResourceValue(corev1.ResourceCPU, v)
+}
+
+func formatMemory(v float64) string {
+	return FormatResourceValue(corev1.ResourceMemory, v)
+}
+
+func formatPercentage(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64) + "%"
+}
+
+// graphLine is a constant line drawn along with the usage, e.g. the limits.
+type graphLine struct {
+	name  string
+	value float64
+	label string
+	color termui.Color
+	// false if the line does not exist, e.g. no limits
+	ok bool
+}
+
+// setGraph shows the usage with the lines, and scales the graph by the scale:
+// to the usage, to fit them all, or to the fixed value.
+func (m *Monitor) setGraph(graph *ui.Graph, header string, format func(float64) string, fixed float64,
+	usageName string, usage []float64, usageLabel string, lines ...graphLine) {
+	graph.LabelHeader = header
+	graph.FormatValue = format
+	graph.Series = make([]ui.Series, 0, len(lines)+1)
+	graph.UpperLimit = 0
+	for _, line := range lines {
+		if !line.ok {
+			continue
+		}
+		graph.Series = append(graph.Series, ui.Series{
+			Name:  line.name,
+			Label: line.label,
+			Data:  ui.ConstantData(line.value, len(usage)),
+			Color: line.color,
+		})
+		graph.UpperLimit = math.Max(graph.UpperLimit, line.value)
+	}
+	graph.Series = append(graph.Series, ui.Series{
+		Name:  usageName,
+		Label: usageLabel,
+		Data:  usage,
+		Color: m.colors.GraphData,
+	})
+	for _, v := range usage {
+		// gaps of the missed samples are NaN
+		if !math.IsNaN(v) {
+			graph.UpperLimit = math.Max(graph.UpperLimit, v)
+		}
+	}
+
+	// the graph scales itself to the usage it shows
+	graph.AutoScale = false
+	switch m.graphScaleCircle.Value.(string) {
+	case AutoScale:
+		graph.AutoScale = true
+	case FixedScale:
+		graph.UpperLimit = fixed
+	}
+}
+
+// podGraphLines returns the lines of the allocatable, the limits and the requests.
+func (m *Monitor) podGraphLines(allocatable corev1.ResourceList, name corev1.ResourceName,
+	limit float64, limitStr string, limitOk bool,
+	request float64, requestStr string, requestOk bool) []graphLine {
+	_, allocatableOk := allocatable[name]
+	return []graphLine{
+		{nodeAllocatableLabel, GetResourceValue(allocatable, name), GetResourceValueString(allocatable, name), m.colors.GraphAllocatable, allocatableOk},
+		{limitLabel, limit, limitStr, m.colors.GraphLimit, limitOk},
+		{requestLabel, request, requestStr, m.colors.GraphRequest, requestOk},
+	}
 }
 
 func (m *Monitor) updateSummarizedGraph(nodeList *corev1.NodeList, summarized *resource.SummarizedResource) error {
-	cpuUsage, cpuUsageStr := summarized.GetCpuUsage()
-	memUsage, memUsageStr := summarized.GetMemoryUsage()
-	node := FindNode(summarized.GetNodeName(), nodeList.Items)
-	limitCpu := GetResourceValue(node.Status.Allocatable, corev1.ResourceCPU)
-	limitCpuStr := GetResourceValueString(node.Status.Allocatable, corev1.ResourceCPU)
-	limitMemory := GetResourceValue(node.Status.Allocatable, corev1.ResourceMemory)
-	limitMemoryStr := GetResourceValueString(node.Status.Allocatable, corev1.ResourceMemory)
-
-	m.cpuGraph.LabelHeader = fmt.Sprintf("Name: %v", summarized.GetPodName())
-	m.cpuGraph.Data = append(m.cpuGraph.Data, cpuUsage)
-	m.cpuGraph.LabelData = fmt.Sprintf("Usage: %v", cpuUsageStr)
-	m.cpuGraph.UpperLimit = limitCpu
-	m.cpuGraph.DrawUpperLimit = false
-	m.cpuGraph.LabelUpperLimit = fmt.Sprintf("%v: %v", nodeAllocatableLabel, limitCpuStr)
-
-	m.memGraph.LabelHeader = fmt.Sprintf("Name: %v", summarized.GetPodName())
-	m.memGraph.Data = append(m.memGraph.Data, memUsage)
-	m.memGraph.LabelData = fmt.Sprintf("Usage: %v", memUsageStr)
-	m.memGraph.UpperLimit = limitMemory
-	m.memGraph.DrawUpperLimit = false
-	m.memGraph.LabelUpperLimit = fmt.Sprintf("%v: %v", nodeAllocatableLabel, limitMemoryStr)
+	_, cpuUsageStr := summarized.GetCpuUsage()
+	_, memUsageStr := summarized.GetMemoryUsage()
+	cpuData, memData := m.history.Get(summarized.Key())
+	// the node is out of the node selector or not scheduled yet
+	var allocatable corev1.ResourceList
+	if node := FindNode(summarized.GetNodeName(), nodeList.Items); node != nil {
+		allocatable = node.Status.Allocatable
+	}
+	header := fmt.Sprintf("Name: %v", summarized.GetPodName())
+
+	cpuLimit, cpuLimitStr, cpuLimitOk := summarized.GetCpuLimits()
+	cpuRequest, cpuRequestStr, cpuRequestOk := summarized.GetCpuRequests()
+	m.setGraph(m.cpuGraph, header, formatCPU, m.graphRange.CPU, "Usage", cpuData, cpuUsageStr,
+		m.podGraphLines(allocatable, corev1.ResourceCPU,
+			cpuLimit, cpuLimitStr, cpuLimitOk,
+			cpuRequest, cpuRequestStr, cpuRequestOk)...)
+
+	memLimit, memLimitStr, memLimitOk := summarized.GetMemoryLimits()
+	memRequest, memRequestStr, memRequestOk := summarized.GetMemoryRequests()
+	m.setGraph(m.memGraph, header, formatMemory, m.graphRange.Memory, "Usage", memData, memUsageStr,
+		m.podGraphLines(allocatable, corev1.ResourceMemory,
+			memLimit, memLimitStr, memLimitOk,
+			memRequest, memRequestStr, memRequestOk)...)
 	return nil
 }
 
 func (m *Monitor) updateAllGraph(nodeList *corev1.NodeList, all *resource.Resource) error {
-	cpuUsage, cpuUsageStr := all.GetCpuUsage()
-	memUsage, memUsageStr := all.GetMemoryUsage()
-
-	limitCpuLabel := containerLimitLabel
-	limitCpu, limitCpuStr, cok := all.GetCpuLimits()
-	limitMemoryLabel := containerLimitLabel
-	limitMemory, limitMemoryStr, mok := all.GetMemoryLimits()
-
-	var node *corev1.Node
-	if !cok || !mok {
-		node = FindNode(all.GetNodeName(), nodeList.Items)
-	}
-	if !cok {
-		limitCpuLabel = nodeAllocatableLabel
-		limitCpu = GetResourceValue(node.Status.Allocatable, corev1.ResourceCPU)
-		limitCpuStr = GetResourceValueString(node.Status.Allocatable, corev1.ResourceCPU)
-	}
-	if !mok {
-		limitMemoryLabel = nodeAllocatableLabel
-		limitMemory = GetResourceValue(node.Status.Allocatable, corev1.ResourceMemory)
-		limitMemoryStr = GetResourceValueString(node.Status.Allocatable, corev1.ResourceMemory)
-	}
-
-	m.cpuGraph.LabelHeader = fmt.Sprintf("Name: %v", all.GetContainerName())
-	m.cpuGraph.Data = append(m.cpuGraph.Data, cpuUsage)
-	m.cpuGraph.LabelData = fmt.Sprintf("Usage: %v", cpuUsageStr)
-	m.cpuGraph.UpperLimit = limitCpu
-	m.cpuGraph.DrawUpperLimit = false
-	m.cpuGraph.LabelUpperLimit = fmt.Sprintf("%v: %v", limitCpuLabel, limitCpuStr)
-
-	m.memGraph.LabelHeader = fmt.Sprintf("Name: %v", all.GetContainerName())
-	m.memGraph.Data = append(m.memGraph.Data, memUsage)
-	m.memGraph.LabelData = fmt.Sprintf("Usage: %v", memUsageStr)
-	m.memGraph.UpperLimit = limitMemory
-	m.memGraph.DrawUpperLimit = false
-	m.memGraph.LabelUpperLimit = fmt.Sprintf("%v: %v", limitMemoryLabel, limitMemoryStr)
+	_, cpuUsageStr := all.GetCpuUsage()
+	_, memUsageStr := all.GetMemoryUsage()
+	cpuData, memData := m.history.Get(all.Key())
+	var allocatable corev1.ResourceList
+	if node := FindNode(all.GetNodeName(), nodeList.Items); node != nil {
+		allocatable = node.Status.Allocatable
+	}
+	header := fmt.Sprintf("Name: %v", all.GetContainerName())
+
+	cpuLimit, cpuLimitStr, cpuLimitOk := all.GetCpuLimits()
+	cpuRequest, cpuRequestStr, cpuRequestOk := all.GetCpuRequests()
+	m.setGraph(m.cpuGraph, header, formatCPU, m.graphRange.CPU, "Usage", cpuData, cpuUsageStr,
+		m.podGraphLines(allocatable, corev1.ResourceCPU,
+			cpuLimit, cpuLimitStr, cpuLimitOk,
+			cpuRequest, cpuRequestStr, cpuRequestOk)...)
+
+	memLimit, memLimitStr, memLimitOk := all.GetMemoryLimits()
+	memRequest, memRequestStr, memRequestOk := all.GetMemoryRequests()
+	m.setGraph(m.memGraph, header, formatMemory, m.graphRange.Memory, "Usage", memData, memUsageStr,
+		m.podGraphLines(allocatable, corev1.ResourceMemory,
+			memLimit, memLimitStr, memLimitOk,
+			memRequest, memRequestStr, memRequestOk)...)
+	return nil
+}
+
+// updateWorkloadGraph shows the total of the replicas, which may be on any nodes.
+func (m *Monitor) updateWorkloadGraph(workload *resource.WorkloadResource) error {
+	_, cpuUsageStr := workload.GetCpuUsage()
+	_, memUsageStr := workload.GetMemoryUsage()
+	cpuData, memData := m.history.Get(workload.Key())
+	header := fmt.Sprintf("Name: %v, Replicas: %v", workload.GetWorkloadName(), workload.GetReplicas())
+	fixed := m.graphRange.Times(workload.GetReplicas())
+
+	cpuLimit, cpuLimitStr, cpuLimitOk := workload.GetCpuLimits()
+	cpuRequest, cpuRequestStr, cpuRequestOk := workload.GetCpuRequests()
+	m.setGraph(m.cpuGraph, header, formatCPU, fixed.CPU, "Usage", cpuData, cpuUsageStr,
+		m.podGraphLines(nil, corev1.ResourceCPU,
+			cpuLimit, cpuLimitStr, cpuLimitOk,
+			cpuRequest, cpuRequestStr, cpuRequestOk)...)
+
+	memLimit, memLimitStr, memLimitOk := workload.GetMemoryLimits()
+	memRequest, memRequestStr, memRequestOk := workload.GetMemoryRequests()
+	m.setGraph(m.memGraph, header, formatMemory, fixed.Memory, "Usage", memData, memUsageStr,
+		m.podGraphLines(nil, corev1.ResourceMemory,
+			memLimit, memLimitStr, memLimitOk,
+			memRequest, memRequestStr, memRequestOk)...)
+	return nil
+}
+
+// updateNamespaceGraph shows the total of the pods in the namespace with its quotas.
+func (m *Monitor) updateNamespaceGraph(namespace *resource.NamespaceResource) error {
+	_, cpuUsageStr := namespace.GetCpuUsage()
+	_, memUsageStr := namespace.GetMemoryUsage()
+	cpuData, memData := m.history.Get(namespace.Key())
+	header := fmt.Sprintf("Name: %v, Pods: %v", namespace.GetNamespace(), namespace.GetPods())
+	fixed := m.graphRange.Times(namespace.GetPods())
+
+	cpuLimit, cpuLimitStr, cpuLimitOk := namespace.GetCpuLimits()
+	cpuRequest, cpuRequestStr, cpuRequestOk := namespace.GetCpuRequests()
+	cpuQuotaLimit, cpuQuotaLimitStr, cpuQuotaLimitOk := namespace.GetCpuQuotaLimits()
+	cpuQuotaRequest, cpuQuotaRequestStr, cpuQuotaRequestOk := namespace.GetCpuQuotaRequests()
+	m.setGraph(m.cpuGraph, header, formatCPU, fixed.CPU, "Usage", cpuData, cpuUsageStr,
+		append([]graphLine{
+			{limitQuotaLabel, cpuQuotaLimit, cpuQuotaLimitStr, m.colors.GraphQuota, cpuQuotaLimitOk},
+			{requestQuotaLabel, cpuQuotaRequest, cpuQuotaRequestStr, m.colors.GraphQuota, cpuQuotaRequestOk},
+		}, m.podGraphLines(nil, corev1.ResourceCPU,
+			cpuLimit, cpuLimitStr, cpuLimitOk,
+			cpuRequest, cpuRequestStr, cpuRequestOk)...)...)
+
+	memLimit, memLimitStr, memLimitOk := namespace.GetMemoryLimits()
+	memRequest, memRequestStr, memRequestOk := namespace.GetMemoryRequests()
+	memQuotaLimit, memQuotaLimitStr, memQuotaLimitOk := namespace.GetMemoryQuotaLimits()
+	memQuotaRequest, memQuotaRequestStr, memQuotaRequestOk := namespace.GetMemoryQuotaRequests()
+	m.setGraph(m.memGraph, header, formatMemory, fixed.Memory, "Usage", memData, memUsageStr,
+		append([]graphLine{
+			{limitQuotaLabel, memQuotaLimit, memQuotaLimitStr, m.colors.GraphQuota, memQuotaLimitOk},
+			{requestQuotaLabel, memQuotaRequest, memQuotaRequestStr, m.colors.GraphQuota, memQuotaRequestOk},
+		}, m.podGraphLines(nil, corev1.ResourceMemory,
+			memLimit, memLimitStr, memLimitOk,
+			memRequest, memRequestStr, memRequestOk)...)...)
+	return nil
+}
+
+// updateGroupGraph shows the total of the pods with the value of the label.
+func (m *Monitor) updateGroupGraph(group *resource.GroupResource) error {
+	_, cpuUsageStr := group.GetCpuUsage()
+	_, memUsageStr := group.GetMemoryUsage()
+	cpuData, memData := m.history.Get(group.Key())
+	header := fmt.Sprintf("Name: %v, Pods: %v", group.GetGroupName(), group.GetPods())
+	fixed := m.graphRange.Times(group.GetPods())
+
+	cpuLimit, cpuLimitStr, cpuLimitOk := group.GetCpuLimits()
+	cpuRequest, cpuRequestStr, cpuRequestOk := group.GetCpuRequests()
+	m.setGraph(m.cpuGraph, header, formatCPU, fixed.CPU, "Usage", cpuData, cpuUsageStr,
+		m.podGraphLines(nil, corev1.ResourceCPU,
+			cpuLimit, cpuLimitStr, cpuLimitOk,
+			cpuRequest, cpuRequestStr, cpuRequestOk)...)
+
+	memLimit, memLimitStr, memLimitOk := group.GetMemoryLimits()
+	memRequest, memRequestStr, memRequestOk := group.GetMemoryRequests()
+	m.setGraph(m.memGraph, header, formatMemory, fixed.Memory, "Usage", memData, memUsageStr,
+		m.podGraphLines(nil, corev1.ResourceMemory,
+			memLimit, memLimitStr, memLimitOk,
+			memRequest, memRequestStr, memRequestOk)...)
 	return nil
 }
 
 func (m *Monitor) updateNodeGraph(node *resource.NodeResource) error {
-	cpuUsage, cpuUsageStr := node.GetCpuUsagePercentage()
-	memUsage, memUsageStr := node.GetMemoryUsagePercentage()
-
-	m.cpuGraph.LabelHeader = fmt.Sprintf("Name: %v", node.GetNodeName())
-	m.cpuGraph.Data = append(m.cpuGraph.Data, cpuUsage)
-	m.cpuGraph.UpperLimit = 100.
-	m.cpuGraph.DrawUpperLimit = false
-	m.cpuGraph.LabelData = fmt.Sprintf("%%Usage: %v", cpuUsageStr)
-
-	m.memGraph.LabelHeader = fmt.Sprintf("Name: %v", node.GetNodeName())
-	m.memGraph.Data = append(m.memGraph.Data, memUsage)
-	m.memGraph.UpperLimit = 100.
-	m.memGraph.DrawUpperLimit = false
-	m.memGraph.LabelData = fmt.Sprintf("%%Usage: %v", memUsageStr)
+	_, cpuUsageStr := node.GetCpuUsagePercentage()
+	_, memUsageStr := node.GetMemoryUsagePercentage()
+	cpuData, memData := m.history.Get(node.Key())
+	header := fmt.Sprintf("Name: %v", node.GetNodeName())
+
+	cpuRequest, cpuRequestStr := node.GetCpuRequestPercentage()
+	cpuLimit, cpuLimitStr := node.GetCpuLimitPercentage()
+	m.setGraph(m.cpuGraph, header, formatPercentage, 100, "%Usage", cpuData, cpuUsageStr,
+		graphLine{nodeAllocatableLabel, 100., "100%", m.colors.GraphAllocatable, true},
+		graphLine{"%" + limitLabel, cpuLimit, cpuLimitStr, m.colors.GraphLimit, node.HasPods()},
+		graphLine{"%" + requestLabel, cpuRequest, cpuRequestStr, m.colors.GraphRequest, node.HasPods()},
+	)
+
+	memRequest, memRequestStr := node.GetMemoryRequestPercentage()
+	memLimit, memLimitStr := node.GetMemoryLimitPercentage()
+	m.setGraph(m.memGraph, header, formatPercentage, 100, "%Usage", memData, memUsageStr,
+		graphLine{nodeAllocatableLabel, 100., "100%", m.colors.GraphAllocatable, true},
+		graphLine{"%" + limitLabel, memLimit, memLimitStr, m.colors.GraphLimit, node.HasPods()},
+		graphLine{"%" + requestLabel, memRequest, memRequestStr, m.colors.GraphRequest, node.HasPods()},
+	)
 	return nil
 }
@@ -0,0 +1,120 @@
+package ktop
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/ynqa/ktop/pkg/resource"
+)
+
+const (
+	// output formats
+	TableOutput = "table"
+	JSONOutput  = "json"
+	YAMLOutput  = "yaml"
+	CSVOutput   = "csv"
+)
+
+// Printer writes the tables as text, for scripting instead of the dashboard.
+type Printer struct {
+	w       io.Writer
+	format  string
+	printed int
+}
+
+func NewPrinter(w io.Writer, format string) (*Printer, error) {
+	switch format {
+	case TableOutput, JSONOutput, YAMLOutput, CSVOutput:
+	default:
+		return nil, errors.Errorf("Unknown output format: %v", format)
+	}
+	return &Printer{
+		w:      w,
+		format: format,
+	}, nil
+}
+
+// Print writes the rows of the viewer. Each call is separated from
+// the previous one in the way of the format.
+func (p *Printer) Print(viewer resource.ResourceTableViewer) error {
+	header, rows := viewer.GetHeader(), viewer.GetRows()
+	var err error
+	switch p.format {
+	case TableOutput:
+		err = p.printTable(header, rows)
+	case JSONOutput:
+		err = p.printJSON(header, rows)
+	case YAMLOutput:
+		err = p.printYAML(header, rows)
+	case CSVOutput:
+		err = p.printCSV(header, rows)
+	}
+	if err != nil {
+		return err
+	}
+	p.printed++
+	return nil
+}
+
+func (p *Printer) printTable(header []string, rows [][]string) error {
+	if p.printed > 0 {
+		fmt.Fprintln(p.w)
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func (p *Printer) printJSON(header []string, rows [][]string) error {
+	encoder := json.NewEncoder(p.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(toRecords(header, rows))
+}
+
+func (p *Printer) printYAML(header []string, rows [][]string) error {
+	b, err := yaml.Marshal(toRecords(header, rows))
+	if err != nil {
+		return err
+	}
+	if p.printed > 0 {
+		fmt.Fprintln(p.w, "---")
+	}
+	_, err = p.w.Write(b)
+	return err
+}
+
+// printCSV writes the header only once, so that the cycles make up one table.
+func (p *Printer) printCSV(header []string, rows [][]string) error {
+	w := csv.NewWriter(p.w)
+	if p.printed == 0 {
+		if err := w.Write(header); err != nil {
+			return err
+		}
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return w.Error()
+}
+
+func toRecords(header []string, rows [][]string) []map[string]string {
+	records := make([]map[string]string, len(rows))
+	for i, row := range rows {
+		record := make(map[string]string, len(header))
+		for j, h := range header {
+			record[h] = row[j]
+		}
+		records[i] = record
+	}
+	return records
+}
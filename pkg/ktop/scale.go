@@ -0,0 +1,104 @@
+package ktop
+
+import (
+	"container/ring"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	. "github.com/ynqa/ktop/pkg/util"
+)
+
+// scales of the graphs
+const (
+	// to fit the usage, the requests, the limits and the allocatable
+	LimitScale = "limit"
+	// to the usage on the graph, so that small usage is visible,
+	// while the other lines may be over the top
+	AutoScale = "auto"
+	// to the fixed range, which is 100% for nodes
+	FixedScale = "fixed"
+)
+
+var GraphScales = []string{LimitScale, AutoScale, FixedScale}
+
+// GraphRange is the fixed range of the graphs for pods and containers,
+// in millicores and MiB as the usage on them. 0 means not fixed.
+type GraphRange struct {
+	CPU    float64
+	Memory float64
+}
+
+// ParseGraphRange parses the quantities of the range, e.g. 500m and 1Gi.
+// Empty quantities are not fixed.
+func ParseGraphRange(cpu, memory string) (GraphRange, error) {
+	lst := corev1.ResourceList{}
+	for name, value := range map[corev1.ResourceName]string{
+		corev1.ResourceCPU:    cpu,
+		corev1.ResourceMemory: memory,
+	} {
+		if value == "" {
+			continue
+		}
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return GraphRange{}, errors.Wrapf(err, "Invalid graph range of %v", name)
+		}
+		lst[name] = q
+	}
+	return GraphRange{
+		CPU:    GetResourceValue(lst, corev1.ResourceCPU),
+		Memory: GetResourceValue(lst, corev1.ResourceMemory),
+	}, nil
+}
+
+// IsFixed returns true if both of the ranges are set.
+func (r GraphRange) IsFixed() bool {
+	return r.CPU > 0 && r.Memory > 0
+}
+
+// Times returns the range for the sum of the pods, e.g. of a workload.
+func (r GraphRange) Times(pods int) GraphRange {
+	n := float64(IntMax(pods, 1))
+	return GraphRange{
+		CPU:    r.CPU * n,
+		Memory: r.Memory * n,
+	}
+}
+
+// ParseGraphScale finds the scale by its name, ignoring case.
+// The fixed scale needs the range.
+func ParseGraphScale(name string, r GraphRange) (string, error) {
+	for _, scale := range GraphScales {
+		if strings.EqualFold(scale, name) {
+			if scale == FixedScale && !r.IsFixed() {
+				return "", errors.New("Fixed graph scale needs the range of both cpu and memory")
+			}
+			return scale, nil
+		}
+	}
+	return "", errors.Errorf("Unknown graph scale: %v", name)
+}
+
+// graphScaleCircle returns the scales to switch between,
+// without the fixed one unless the range is set.
+func graphScaleCircle(scale string, r GraphRange) *ring.Ring {
+	scales := make([]string, 0, len(GraphScales))
+	for _, s := range GraphScales {
+		if s != FixedScale || r.IsFixed() {
+			scales = append(scales, s)
+		}
+	}
+	circle := ring.New(len(scales))
+	for _, s := range scales {
+		circle.Value = s
+		circle = circle.Next()
+	}
+	// start from the scale
+	for i := 0; i < circle.Len() && circle.Value.(string) != scale; i++ {
+		circle = circle.Next()
+	}
+	return circle
+}
@@ -0,0 +1,113 @@
+package kube
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/kubernetes/pkg/kubectl/metricsutil"
+	"k8s.io/metrics/pkg/apis/metrics"
+)
+
+const (
+	// metrics backends
+	AutoBackend          = "auto"
+	MetricsServerBackend = "metrics-server"
+	HeapsterBackend      = "heapster"
+	PrometheusBackend    = "prometheus"
+	ReplayBackend        = "replay"
+)
+
+// MetricsBackends are the backends to choose with MetricsOptions.
+var MetricsBackends = []string{AutoBackend, MetricsServerBackend, HeapsterBackend, PrometheusBackend}
+
+// newMetricsClient creates the client for the backend, after probing that it is available.
+// The auto backend takes Prometheus if its URL is given, and otherwise
+// metrics-server or heapster in that order.
+func newMetricsClient(opts MetricsOptions, config *rest.Config, clientset *kubernetes.Clientset) (metricsClient, string, error) {
+	switch opts.Backend {
+	case "", AutoBackend:
+		if opts.PrometheusURL != "" {
+			return newMetricsClient(withBackend(opts, PrometheusBackend), config, clientset)
+		}
+		mergedErr := errors.New("Failed to create metrics client")
+		for _, backend := range []string{MetricsServerBackend, HeapsterBackend} {
+			client, name, err := newMetricsClient(withBackend(opts, backend), config, clientset)
+			if err == nil {
+				return client, name, nil
+			}
+			mergedErr = errors.Wrap(mergedErr, err.Error())
+		}
+		return nil, "", mergedErr
+	case MetricsServerBackend:
+		if err := probeMetricsAPI(clientset); err != nil {
+			return nil, "", err
+		}
+		client, err := newMetricsServerClient(config)
+		if err != nil {
+			return nil, "", err
+		}
+		return client, MetricsServerBackend, nil
+	case HeapsterBackend:
+		if err := probeHeapster(clientset); err != nil {
+			return nil, "", err
+		}
+		client, err := newHeapsterClient(clientset.CoreV1())
+		if err != nil {
+			return nil, "", err
+		}
+		return client, HeapsterBackend, nil
+	case PrometheusBackend:
+		if opts.PrometheusURL == "" {
+			return nil, "", errors.New("Prometheus URL is required for the prometheus backend")
+		}
+		client, err := newPrometheusClient(opts.PrometheusURL, &http.Client{Timeout: prometheusTimeout})
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := client.query("vector(1)"); err != nil {
+			return nil, "", errors.Wrap(err, "Prometheus is not available")
+		}
+		return client, PrometheusBackend, nil
+	default:
+		return nil, "", errors.Errorf("Unknown metrics backend: %v, one of: %v",
+			opts.Backend, strings.Join(MetricsBackends, "|"))
+	}
+}
+
+func withBackend(opts MetricsOptions, backend string) MetricsOptions {
+	opts.Backend = backend
+	return opts
+}
+
+// probeMetricsAPI checks that the metrics.k8s.io API group is served,
+// since creating the client for it never fails.
+func probeMetricsAPI(clientset *kubernetes.Clientset) error {
+	groups, err := clientset.Discovery().ServerGroups()
+	if err != nil {
+		return errors.Wrap(err, "Failed to discover API groups")
+	}
+	for _, group := range groups.Groups {
+		if group.Name == metrics.GroupName {
+			return nil
+		}
+	}
+	return errors.Errorf("%v API is not available", metrics.GroupName)
+}
+
+// probeHeapster checks that the service of heapster exists,
+// since creating the client for it never fails.
+func probeHeapster(clientset *kubernetes.Clientset) error {
+	_, err := clientset.CoreV1().
+		Services(metricsutil.DefaultHeapsterNamespace).
+		Get(metricsutil.DefaultHeapsterService, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return errors.New("heapster is not available")
+	}
+	return err
+}
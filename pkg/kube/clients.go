@@ -1,28 +1,157 @@
 package kube
 
 import (
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/pkg/errors"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	appsv1lister "k8s.io/client-go/listers/apps/v1"
+	corev1lister "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/kubernetes/pkg/kubectl/metricsutil"
 	"k8s.io/metrics/pkg/apis/metrics"
 	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	"k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
+// activePodsSelector drops the pods which have finished,
+// and do not hold resources on the nodes anymore.
+var activePodsSelector = fields.AndSelectors(
+	fields.OneTermNotEqualSelector("status.phase", string(corev1.PodSucceeded)),
+	fields.OneTermNotEqualSelector("status.phase", string(corev1.PodFailed)),
+)
+
+const (
+	// cacheSyncTimeout is how long to wait for the informer caches to be
+	// filled with the initial listing before giving up.
+	cacheSyncTimeout = 30 * time.Second
+	// optionalCacheSyncTimeout is how long to wait for the optional caches,
+	// which may never be filled if they are not allowed to list.
+	optionalCacheSyncTimeout = 5 * time.Second
+)
+
+// Scope narrows down the pods and nodes to watch.
+type Scope struct {
+	AllNamespaces bool
+	// label selector for pods
+	PodSelector string
+	// field selector for pods, e.g. spec.nodeName=node-1,status.phase=Running
+	PodFieldSelector string
+	// label selector for nodes
+	NodeSelector string
+}
+
 type KubeClients struct {
-	Flags         *genericclioptions.ConfigFlags
+	Flags *genericclioptions.ConfigFlags
+	// Namespaces to watch pods in, metav1.NamespaceAll stands for all namespaces.
+	Namespaces   []string
+	PodSelector  labels.Selector
+	NodeSelector labels.Selector
+
 	clientset     *kubernetes.Clientset
 	metricsClient metricsClient
+
+	// health of the metrics backend
+	metricsMutex       sync.Mutex
+	metricsBackend     string
+	lastMetricsSuccess time.Time
+
+	// the last error of the informers, which retry on their own
+	informerMutex       sync.Mutex
+	lastInformerErr     string
+	lastInformerErrTime time.Time
+	// utilruntime.ErrorHandlers to restore on Close, nil if not replaced
+	errorHandlers []func(error)
+
+	// pods and nodes are served from watch-based caches,
+	// only metrics are polled.
+	informerFactories []informers.SharedInformerFactory
+	// by the namespaces to watch, or metav1.NamespaceAll for all namespaces
+	podListers map[string]corev1lister.PodLister
+	nodeLister corev1lister.NodeLister
+	// pods on the nodes out of the pod scope,
+	// nil if the pod scope is not narrowed down.
+	// They are waited for a while only, since listing them needs to be allowed cluster-wide.
+	nodePodLister corev1lister.PodLister
+	nodePodSynced func() bool
+	// replica sets to resolve the deployments of pods, and quotas of the namespaces,
+	// which are waited for a while only, since they may not be allowed to list.
+	// See GetReplicaSetList and GetResourceQuotaList.
+	optionalListers []*optionalListers
+	// synced of the optional listers, see waitForOptionalCacheSync
+	optionalSynced []cache.InformerSynced
+	stopCh         chan struct{}
+	// time of the objects listed, which is of the frame on replaying
+	now func() time.Time
+}
+
+// optionalListers list the replica sets and the quotas in a namespace to watch.
+type optionalListers struct {
+	replicaSetLister    appsv1lister.ReplicaSetLister
+	replicaSetSynced    func() bool
+	resourceQuotaLister corev1lister.ResourceQuotaLister
+	resourceQuotaSynced func() bool
+}
+
+// parsedScope is the scope parsed into selectors.
+type parsedScope struct {
+	namespaces       []string
+	podSelector      labels.Selector
+	podFieldSelector fields.Selector
+	nodeSelector     labels.Selector
 }
 
-func NewKubeClients(flags *genericclioptions.ConfigFlags) (*KubeClients, error) {
+func (s Scope) parse(namespace string) (*parsedScope, error) {
+	podSelector, err := labels.Parse(s.PodSelector)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid pod selector")
+	}
+	podFieldSelector, err := fields.ParseSelector(s.PodFieldSelector)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid pod field selector")
+	}
+	nodeSelector, err := labels.Parse(s.NodeSelector)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid node selector")
+	}
+	return &parsedScope{
+		namespaces:       parseNamespaces(namespace, s.AllNamespaces),
+		podSelector:      podSelector,
+		podFieldSelector: podFieldSelector,
+		nodeSelector:     nodeSelector,
+	}, nil
+}
+
+// MetricsOptions configures where metrics come from.
+type MetricsOptions struct {
+	// one of MetricsBackends
+	Backend string
+	// URL of Prometheus to query cAdvisor metrics instead of metrics-server
+	PrometheusURL string
+}
+
+func NewKubeClients(flags *genericclioptions.ConfigFlags, scope Scope, metricsOpts MetricsOptions) (*KubeClients, error) {
+	parsed, err := scope.parse(*flags.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, podSelector, podFieldSelector, nodeSelector :=
+		parsed.namespaces, parsed.podSelector, parsed.podFieldSelector, parsed.nodeSelector
+
 	config, err := flags.ToRESTConfig()
 	if err != nil {
 		return nil, err
@@ -31,38 +160,358 @@ func NewKubeClients(flags *genericclioptions.ConfigFlags) (*KubeClients, error)
 	if err != nil {
 		return nil, err
 	}
-	var metricsClient metricsClient
-	mergedErr := errors.New("Failed to create metrics client")
-	metricsClient, err = newMetricsServerClient(config)
+	metricsClient, backend, err := newMetricsClient(metricsOpts, config, clientset)
 	if err != nil {
-		mergedErr = errors.Wrap(mergedErr, err.Error())
-		metricsClient, err = newHeapsterClient(clientset.CoreV1())
-		if err != nil {
-			mergedErr = errors.Wrap(mergedErr, err.Error())
-			return nil, mergedErr
+		return nil, err
+	}
+	nodeInformerFactory := informers.NewSharedInformerFactoryWithOptions(
+		clientset,
+		0,
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = nodeSelector.String()
+		}),
+	)
+	clients := &KubeClients{
+		Flags:             flags,
+		Namespaces:        namespaces,
+		PodSelector:       podSelector,
+		NodeSelector:      nodeSelector,
+		clientset:         clientset,
+		metricsClient:     metricsClient,
+		metricsBackend:    backend,
+		informerFactories: []informers.SharedInformerFactory{nodeInformerFactory},
+		podListers:        make(map[string]corev1lister.PodLister),
+		nodeLister:        nodeInformerFactory.Core().V1().Nodes().Lister(),
+		nodePodSynced:     func() bool { return true },
+		optionalListers:   make([]*optionalListers, 0, len(namespaces)),
+		stopCh:            make(chan struct{}),
+		now:               time.Now,
+	}
+	optionalInformerFactories := make([]informers.SharedInformerFactory, 0)
+	// each namespace is watched apart, so that users allowed
+	// in the namespaces only can watch them.
+	for _, namespace := range namespaces {
+		// selectors are pushed down to the API server,
+		// so that the caches hold only the objects to show.
+		podInformerFactory := informers.NewSharedInformerFactoryWithOptions(
+			clientset,
+			0,
+			informers.WithNamespace(namespace),
+			informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+				opts.LabelSelector = podSelector.String()
+				opts.FieldSelector = podFieldSelector.String()
+			}),
+		)
+		clients.informerFactories = append(clients.informerFactories, podInformerFactory)
+		clients.podListers[namespace] = podInformerFactory.Core().V1().Pods().Lister()
+
+		optionalInformerFactory := informers.NewSharedInformerFactoryWithOptions(
+			clientset,
+			0,
+			informers.WithNamespace(namespace),
+		)
+		optionalInformerFactories = append(optionalInformerFactories, optionalInformerFactory)
+		replicaSetInformer := optionalInformerFactory.Apps().V1().ReplicaSets()
+		resourceQuotaInformer := optionalInformerFactory.Core().V1().ResourceQuotas()
+		clients.optionalListers = append(clients.optionalListers, &optionalListers{
+			replicaSetLister:    replicaSetInformer.Lister(),
+			replicaSetSynced:    replicaSetInformer.Informer().HasSynced,
+			resourceQuotaLister: resourceQuotaInformer.Lister(),
+			resourceQuotaSynced: resourceQuotaInformer.Informer().HasSynced,
+		})
+		clients.optionalSynced = append(clients.optionalSynced,
+			replicaSetInformer.Informer().HasSynced,
+			resourceQuotaInformer.Informer().HasSynced,
+		)
+	}
+	// requests and limits on the nodes are committed by all pods on them,
+	// which are watched apart if the pod scope misses some.
+	if namespaces[0] != metav1.NamespaceAll || !podSelector.Empty() || !podFieldSelector.Empty() {
+		nodePodInformerFactory := informers.NewSharedInformerFactoryWithOptions(
+			clientset,
+			0,
+			informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+				opts.FieldSelector = activePodsSelector.String()
+			}),
+		)
+		optionalInformerFactories = append(optionalInformerFactories, nodePodInformerFactory)
+		nodePodInformer := nodePodInformerFactory.Core().V1().Pods()
+		clients.nodePodLister = nodePodInformer.Lister()
+		clients.nodePodSynced = nodePodInformer.Informer().HasSynced
+		clients.optionalSynced = append(clients.optionalSynced, clients.nodePodSynced)
+	}
+	// errors of the informers are remembered for the status. The informers of this client-go
+	// have no SetWatchErrorHandler, so that the global handlers are prepended to until Close.
+	clients.errorHandlers = utilruntime.ErrorHandlers
+	utilruntime.ErrorHandlers = append([]func(error){clients.informerFailed}, clients.errorHandlers...)
+	for _, factory := range clients.informerFactories {
+		factory.Start(clients.stopCh)
+	}
+	for _, factory := range optionalInformerFactories {
+		factory.Start(clients.stopCh)
+	}
+	if err := clients.waitForCacheSync(cacheSyncTimeout); err != nil {
+		clients.Close()
+		return nil, err
+	}
+	clients.waitForOptionalCacheSync(optionalCacheSyncTimeout)
+	return clients, nil
+}
+
+// parseNamespaces splits the comma-separated namespaces.
+func parseNamespaces(namespace string, allNamespaces bool) []string {
+	if allNamespaces {
+		return []string{metav1.NamespaceAll}
+	}
+	namespaces := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, ns := range strings.Split(namespace, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == "" || seen[ns] {
+			continue
 		}
+		seen[ns] = true
+		namespaces = append(namespaces, ns)
 	}
-	return &KubeClients{
-		Flags:         flags,
-		clientset:     clientset,
-		metricsClient: metricsClient,
-	}, nil
+	if len(namespaces) == 0 {
+		return []string{metav1.NamespaceDefault}
+	}
+	return namespaces
+}
+
+func (k *KubeClients) waitForCacheSync(timeout time.Duration) error {
+	stopCh := make(chan struct{})
+	timer := time.AfterFunc(timeout, func() {
+		close(stopCh)
+	})
+	defer timer.Stop()
+	for _, factory := range k.informerFactories {
+		for typ, synced := range factory.WaitForCacheSync(stopCh) {
+			if !synced {
+				return errors.Errorf("Failed to sync cache for %v", typ)
+			}
+		}
+	}
+	return nil
+}
+
+// waitForOptionalCacheSync waits for the optional caches up to the timeout,
+// so that the first collect, e.g. of --output, has them unless they are not allowed to list.
+// Later collects do without them until they are synced.
+func (k *KubeClients) waitForOptionalCacheSync(timeout time.Duration) {
+	stopCh := make(chan struct{})
+	timer := time.AfterFunc(timeout, func() {
+		close(stopCh)
+	})
+	defer timer.Stop()
+	cache.WaitForCacheSync(stopCh, k.optionalSynced...)
+}
+
+// Close stops the informers started by NewKubeClients.
+func (k *KubeClients) Close() {
+	close(k.stopCh)
+	if k.errorHandlers != nil {
+		utilruntime.ErrorHandlers = k.errorHandlers
+	}
+}
+
+// Now returns the time which the objects are listed at,
+// i.e. the current time, or the recorded one on replaying.
+func (k *KubeClients) Now() time.Time {
+	return k.now()
 }
 
 func (k *KubeClients) GetPodList(namespace string, labelSelector labels.Selector) (*corev1.PodList, error) {
-	return k.clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: labelSelector.String()})
+	podLister, ok := k.podListers[namespace]
+	if !ok {
+		podLister, ok = k.podListers[metav1.NamespaceAll]
+	}
+	if !ok {
+		return nil, errors.Errorf("Unknown namespace to watch: %v", namespace)
+	}
+	pods, err := podLister.Pods(namespace).List(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.PodList{
+		Items: make([]corev1.Pod, len(pods)),
+	}
+	for i, pod := range pods {
+		list.Items[i] = *pod
+	}
+	return list, nil
+}
+
+// GetNodePodList returns the active pods on all nodes regardless of the pod scope,
+// or nil if the pods in the scope are all. It reports false if they are not listed yet,
+// e.g. not allowed to list pods cluster-wide.
+func (k *KubeClients) GetNodePodList() (*corev1.PodList, bool, error) {
+	if k.nodePodLister == nil {
+		return nil, true, nil
+	}
+	if !k.nodePodSynced() {
+		return nil, false, nil
+	}
+	pods, err := k.nodePodLister.List(labels.Everything())
+	if err != nil {
+		return nil, false, err
+	}
+	list := &corev1.PodList{
+		Items: make([]corev1.Pod, 0, len(pods)),
+	}
+	for _, pod := range pods {
+		if activePodsSelector.Matches(podFields(pod)) {
+			list.Items = append(list.Items, *pod)
+		}
+	}
+	return list, true, nil
+}
+
+// GetReplicaSetList returns the replica sets in the namespaces of the pods listed so far,
+// or nil if none of them are, e.g. not allowed to.
+func (k *KubeClients) GetReplicaSetList() (*appsv1.ReplicaSetList, error) {
+	var list *appsv1.ReplicaSetList
+	for _, l := range k.optionalListers {
+		if !l.replicaSetSynced() {
+			continue
+		}
+		replicaSets, err := l.replicaSetLister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		if list == nil {
+			list = &appsv1.ReplicaSetList{}
+		}
+		for _, rs := range replicaSets {
+			list.Items = append(list.Items, *rs)
+		}
+	}
+	return list, nil
+}
+
+// GetResourceQuotaList returns the quotas in the namespaces of the pods listed so far,
+// or nil if none of them are, e.g. not allowed to.
+func (k *KubeClients) GetResourceQuotaList() (*corev1.ResourceQuotaList, error) {
+	var list *corev1.ResourceQuotaList
+	for _, l := range k.optionalListers {
+		if !l.resourceQuotaSynced() {
+			continue
+		}
+		quotas, err := l.resourceQuotaLister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		if list == nil {
+			list = &corev1.ResourceQuotaList{}
+		}
+		for _, quota := range quotas {
+			list.Items = append(list.Items, *quota)
+		}
+	}
+	return list, nil
 }
 
 func (k *KubeClients) GetPodMetricsList(namespace string, labelSelector labels.Selector) (*metrics.PodMetricsList, error) {
-	return k.metricsClient.getPodMetricsList(namespace, labelSelector)
+	list, err := k.metricsClient.getPodMetricsList(namespace, labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	k.metricsSucceeded()
+	// cAdvisor metrics do not have labels of pods to select by
+	if k.metricsBackend == PrometheusBackend && !labelSelector.Empty() {
+		pods, err := k.GetPodList(namespace, labelSelector)
+		if err != nil {
+			return nil, err
+		}
+		selected := make(map[string]bool)
+		for _, pod := range pods.Items {
+			selected[pod.Namespace+"/"+pod.Name] = true
+		}
+		items := make([]metrics.PodMetrics, 0, len(list.Items))
+		for _, pm := range list.Items {
+			if selected[pm.Namespace+"/"+pm.Name] {
+				items = append(items, pm)
+			}
+		}
+		list.Items = items
+	}
+	return list, nil
 }
 
 func (k *KubeClients) GetNodeList(labelSelector labels.Selector) (*corev1.NodeList, error) {
-	return k.clientset.CoreV1().Nodes().List(metav1.ListOptions{LabelSelector: labelSelector.String()})
+	nodes, err := k.nodeLister.List(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.NodeList{
+		Items: make([]corev1.Node, len(nodes)),
+	}
+	for i, node := range nodes {
+		list.Items[i] = *node
+	}
+	return list, nil
 }
 
 func (k *KubeClients) GetNodeMetricsList(labelSelector labels.Selector) (*metrics.NodeMetricsList, error) {
-	return k.metricsClient.getNodeMetricsList(labelSelector)
+	list, err := k.metricsClient.getNodeMetricsList(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	k.metricsSucceeded()
+	// as GetPodMetricsList does
+	if k.metricsBackend == PrometheusBackend && !labelSelector.Empty() {
+		nodes, err := k.nodeLister.List(labelSelector)
+		if err != nil {
+			return nil, err
+		}
+		selected := make(map[string]bool)
+		for _, node := range nodes {
+			selected[node.Name] = true
+		}
+		items := make([]metrics.NodeMetrics, 0, len(list.Items))
+		for _, nm := range list.Items {
+			if selected[nm.Name] {
+				items = append(items, nm)
+			}
+		}
+		list.Items = items
+	}
+	return list, nil
+}
+
+func (k *KubeClients) metricsSucceeded() {
+	k.metricsMutex.Lock()
+	defer k.metricsMutex.Unlock()
+	k.lastMetricsSuccess = time.Now()
+}
+
+// informerFailed remembers the error, e.g. Failed to list *v1.Pod: ... is forbidden,
+// without the location of the informer in the source.
+func (k *KubeClients) informerFailed(err error) {
+	msg := err.Error()
+	if i := strings.Index(msg, ": "); i >= 0 && strings.Contains(msg[:i], ".go:") {
+		msg = msg[i+2:]
+	}
+	k.informerMutex.Lock()
+	defer k.informerMutex.Unlock()
+	k.lastInformerErr = msg
+	k.lastInformerErrTime = time.Now()
+}
+
+// GetInformerError returns the last error of the informers, and when it was.
+// The error is empty if none.
+func (k *KubeClients) GetInformerError() (string, time.Time) {
+	k.informerMutex.Lock()
+	defer k.informerMutex.Unlock()
+	return k.lastInformerErr, k.lastInformerErrTime
+}
+
+// GetMetricsHealth returns the name of the metrics backend,
+// and when metrics were fetched from it successfully last time.
+func (k *KubeClients) GetMetricsHealth() (string, time.Time) {
+	k.metricsMutex.Lock()
+	defer k.metricsMutex.Unlock()
+	return k.metricsBackend, k.lastMetricsSuccess
 }
 
 type metricsClient interface {
@@ -126,9 +575,20 @@ func newHeapsterClient(svcClient corev1client.ServicesGetter) (*heapsterClient,
 }
 
 func (c *heapsterClient) getPodMetricsList(namespace string, labelSelector labels.Selector) (*metrics.PodMetricsList, error) {
-	return c.GetPodMetrics(namespace, "", false, labelSelector)
+	return c.GetPodMetrics(namespace, "", namespace == metav1.NamespaceAll, labelSelector)
 }
 
 func (c *heapsterClient) getNodeMetricsList(labelSelector labels.Selector) (*metrics.NodeMetricsList, error) {
 	return c.GetNodeMetrics("", labelSelector.String())
 }
+
+// IsFatal reports whether the error is not worth retrying, i.e. the credentials are rejected.
+// Forbidden is not, since it may be of a namespace only, or be allowed later.
+func IsFatal(err error) bool {
+	return apierrors.IsUnauthorized(errors.Cause(err))
+}
+
+// IsForbidden reports whether the error is of what is not allowed, e.g. in a namespace.
+func IsForbidden(err error) bool {
+	return apierrors.IsForbidden(errors.Cause(err))
+}
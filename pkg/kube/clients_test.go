@@ -0,0 +1,50 @@
+package kube
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestInformerFailed(t *testing.T) {
+	k := &KubeClients{}
+	if msg, _ := k.GetInformerError(); msg != "" {
+		t.Errorf("expected no error, got %q", msg)
+	}
+
+	k.informerFailed(errors.New("k8s.io/client-go/informers/factory.go:134: Failed to list *v1.Pod: pods is forbidden"))
+	msg, at := k.GetInformerError()
+	if expected := "Failed to list *v1.Pod: pods is forbidden"; msg != expected {
+		t.Errorf("expected %q, got %q", expected, msg)
+	}
+	if at.IsZero() {
+		t.Error("expected the time of the error")
+	}
+
+	// errors without the location are kept as is
+	k.informerFailed(errors.New("Failed to watch: connection refused"))
+	if msg, _ := k.GetInformerError(); msg != "Failed to watch: connection refused" {
+		t.Errorf("unexpected error %q", msg)
+	}
+}
+
+func TestIsFatal(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: apierrors.NewUnauthorized("expired"), want: true},
+		{err: errors.Wrap(apierrors.NewUnauthorized("expired"), "Failed to get"), want: true},
+		{err: apierrors.NewForbidden(pods, "", errors.New("in kube-system")), want: false},
+		{err: errors.New("connection refused"), want: false},
+	}
+	for i, tt := range tests {
+		if got := IsFatal(tt.err); got != tt.want {
+			t.Errorf("%v: got %v, want %v", i, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/metrics/pkg/apis/metrics"
+)
+
+const (
+	prometheusTimeout = 10 * time.Second
+	// rateWindow is the range to take cpu rates over, as metrics-server does.
+	rateWindow = time.Minute
+
+	// queries for cAdvisor metrics. The root cgroup "/" stands for the whole node,
+	// which is told by the node label that the scrape configs of kube-prometheus
+	// and the Prometheus chart put on cAdvisor metrics.
+	podCpuQuery = `sum by (namespace, pod, container) ` +
+		`(rate(container_cpu_usage_seconds_total{container!="",container!="POD"%v}[%v]))`
+	podMemoryQuery = `sum by (namespace, pod, container) ` +
+		`(container_memory_working_set_bytes{container!="",container!="POD"%v})`
+	nodeCpuQuery    = `sum by (node) (rate(container_cpu_usage_seconds_total{id="/"}[%v]))`
+	nodeMemoryQuery = `sum by (node) (container_memory_working_set_bytes{id="/"})`
+)
+
+// prometheusClient serves metrics from cAdvisor metrics stored in Prometheus,
+// in place of metrics-server.
+type prometheusClient struct {
+	url    *url.URL
+	client *http.Client
+}
+
+func newPrometheusClient(rawurl string, client *http.Client) (*prometheusClient, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid Prometheus URL")
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, errors.Errorf("Invalid Prometheus URL: %v", rawurl)
+	}
+	return &prometheusClient{
+		url:    u,
+		client: client,
+	}, nil
+}
+
+// the response of the instant query API.
+type prometheusResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string             `json:"resultType"`
+		Result     []prometheusSample `json:"result"`
+	} `json:"data"`
+}
+
+type prometheusSample struct {
+	Metric map[string]string `json:"metric"`
+	// a pair of the unix time and the value as string
+	Value [2]interface{} `json:"value"`
+}
+
+func (s prometheusSample) time() time.Time {
+	t, _ := s.Value[0].(float64)
+	sec := int64(t)
+	return time.Unix(sec, int64((t-float64(sec))*1e9))
+}
+
+func (s prometheusSample) value() (float64, error) {
+	str, ok := s.Value[1].(string)
+	if !ok {
+		return 0, errors.Errorf("Unexpected value from Prometheus: %v", s.Value[1])
+	}
+	v, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "Invalid value from Prometheus")
+	}
+	return v, nil
+}
+
+func (c *prometheusClient) query(query string) ([]prometheusSample, error) {
+	u := *c.url
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/v1/query"
+	u.RawQuery = url.Values{"query": []string{query}}.Encode()
+	resp, err := c.client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body prometheusResponse
+	if resp.StatusCode != http.StatusOK {
+		// errors of the API are in JSON, but not of proxies in front of it
+		snippet, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		if err := json.Unmarshal(snippet, &body); err != nil || body.Status != "error" {
+			return nil, errors.Errorf("Failed to query Prometheus (%v): %v", resp.Status, strings.TrimSpace(string(snippet)))
+		}
+	} else if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, errors.Wrapf(err, "Failed to decode the response from Prometheus (%v)", resp.Status)
+	}
+	if body.Status != "success" {
+		return nil, errors.Errorf("Failed to query Prometheus: %v: %v", body.ErrorType, body.Error)
+	}
+	if body.Data.ResultType != "vector" {
+		return nil, errors.Errorf("Unexpected result type from Prometheus: %v", body.Data.ResultType)
+	}
+	return body.Data.Result, nil
+}
+
+// getPodMetricsList ignores the label selector, since cAdvisor metrics do not have
+// labels of pods. KubeClients drops the pods out of it.
+func (c *prometheusClient) getPodMetricsList(namespace string, labelSelector labels.Selector) (*metrics.PodMetricsList, error) {
+	var matcher string
+	if namespace != metav1.NamespaceAll {
+		matcher = fmt.Sprintf(`,namespace=%q`, namespace)
+	}
+	cpuSamples, err := c.query(fmt.Sprintf(podCpuQuery, matcher, promDuration(rateWindow)))
+	if err != nil {
+		return nil, err
+	}
+	memorySamples, err := c.query(fmt.Sprintf(podMemoryQuery, matcher))
+	if err != nil {
+		return nil, err
+	}
+
+	pods := make(map[string]*metrics.PodMetrics)
+	order := make([]string, 0)
+	containerUsage := func(s prometheusSample) corev1.ResourceList {
+		key := s.Metric["namespace"] + "/" + s.Metric["pod"]
+		pod, ok := pods[key]
+		if !ok {
+			pod = &metrics.PodMetrics{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: s.Metric["namespace"],
+					Name:      s.Metric["pod"],
+				},
+				Timestamp: metav1.NewTime(s.time()),
+				Window:    metav1.Duration{Duration: rateWindow},
+			}
+			pods[key] = pod
+			order = append(order, key)
+		}
+		for i := range pod.Containers {
+			if pod.Containers[i].Name == s.Metric["container"] {
+				return pod.Containers[i].Usage
+			}
+		}
+		pod.Containers = append(pod.Containers, metrics.ContainerMetrics{
+			Name:  s.Metric["container"],
+			Usage: corev1.ResourceList{},
+		})
+		return pod.Containers[len(pod.Containers)-1].Usage
+	}
+	for _, s := range cpuSamples {
+		v, err := s.value()
+		if err != nil {
+			return nil, err
+		}
+		containerUsage(s)[corev1.ResourceCPU] = cpuQuantity(v)
+	}
+	for _, s := range memorySamples {
+		v, err := s.value()
+		if err != nil {
+			return nil, err
+		}
+		containerUsage(s)[corev1.ResourceMemory] = memoryQuantity(v)
+	}
+
+	list := &metrics.PodMetricsList{}
+	for _, key := range order {
+		list.Items = append(list.Items, *pods[key])
+	}
+	return list, nil
+}
+
+// getNodeMetricsList ignores the label selector as getPodMetricsList does.
+func (c *prometheusClient) getNodeMetricsList(labelSelector labels.Selector) (*metrics.NodeMetricsList, error) {
+	cpuSamples, err := c.query(fmt.Sprintf(nodeCpuQuery, promDuration(rateWindow)))
+	if err != nil {
+		return nil, err
+	}
+	memorySamples, err := c.query(nodeMemoryQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string]*metrics.NodeMetrics)
+	order := make([]string, 0)
+	nodeUsage := func(s prometheusSample) (corev1.ResourceList, error) {
+		name := s.Metric["node"]
+		if name == "" {
+			return nil, errors.New("Missing node label on cAdvisor metrics from Prometheus")
+		}
+		node, ok := nodes[name]
+		if !ok {
+			node = &metrics.NodeMetrics{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+				},
+				Timestamp: metav1.NewTime(s.time()),
+				Window:    metav1.Duration{Duration: rateWindow},
+				Usage:     corev1.ResourceList{},
+			}
+			nodes[name] = node
+			order = append(order, name)
+		}
+		return node.Usage, nil
+	}
+	for _, s := range cpuSamples {
+		v, err := s.value()
+		if err != nil {
+			return nil, err
+		}
+		usage, err := nodeUsage(s)
+		if err != nil {
+			return nil, err
+		}
+		usage[corev1.ResourceCPU] = cpuQuantity(v)
+	}
+	for _, s := range memorySamples {
+		v, err := s.value()
+		if err != nil {
+			return nil, err
+		}
+		usage, err := nodeUsage(s)
+		if err != nil {
+			return nil, err
+		}
+		usage[corev1.ResourceMemory] = memoryQuantity(v)
+	}
+
+	list := &metrics.NodeMetricsList{}
+	for _, name := range order {
+		list.Items = append(list.Items, *nodes[name])
+	}
+	return list, nil
+}
+
+// cpuQuantity converts cores into a quantity in millicores.
+func cpuQuantity(cores float64) resource.Quantity {
+	return *resource.NewMilliQuantity(int64(cores*1000), resource.DecimalSI)
+}
+
+func memoryQuantity(bytes float64) resource.Quantity {
+	return *resource.NewQuantity(int64(bytes), resource.BinarySI)
+}
+
+// promDuration formats the duration in seconds for range selectors, e.g. 60s.
+func promDuration(d time.Duration) string {
+	return fmt.Sprintf("%vs", int64(d.Seconds()))
+}
@@ -0,0 +1,268 @@
+package kube
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newTestPrometheus serves the responses by the metric names in the queries.
+func newTestPrometheus(t *testing.T, status int, responses map[string]string) *prometheusClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/prometheus/api/v1/query" {
+			t.Errorf("got path %v", r.URL.Path)
+		}
+		query := r.URL.Query().Get("query")
+		w.WriteHeader(status)
+		for name, body := range responses {
+			if strings.Contains(query, name) {
+				fmt.Fprint(w, body)
+				return
+			}
+		}
+		t.Errorf("got unexpected query %v", query)
+	}))
+	t.Cleanup(server.Close)
+	client, err := newPrometheusClient(server.URL+"/prometheus/", server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// vector returns the response of the samples, which are pairs of the labels and the value.
+func vector(samples ...string) string {
+	results := make([]string, 0, len(samples)/2)
+	for i := 0; i+1 < len(samples); i += 2 {
+		results = append(results, fmt.Sprintf(`{"metric":{%v},"value":[1551398400.5,%v]}`, samples[i], samples[i+1]))
+	}
+	return `{"status":"success","data":{"resultType":"vector","result":[` + strings.Join(results, ",") + `]}}`
+}
+
+func TestPrometheusQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		samples int
+		err     string
+	}{
+		{
+			name:    "vector",
+			status:  http.StatusOK,
+			body:    vector(`"node":"a"`, `"1"`, `"node":"b"`, `"2"`),
+			samples: 2,
+		},
+		{
+			name:   "empty vector",
+			status: http.StatusOK,
+			body:   vector(),
+		},
+		{
+			name:   "error status",
+			status: http.StatusBadRequest,
+			body:   `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+			err:    "Failed to query Prometheus: bad_data: parse error",
+		},
+		{
+			name:   "http error",
+			status: http.StatusBadGateway,
+			body:   "<html><body>Bad Gateway</body></html>",
+			err:    "Failed to query Prometheus (502 Bad Gateway): <html><body>Bad Gateway</body></html>",
+		},
+		{
+			name:   "unauthorized",
+			status: http.StatusUnauthorized,
+			body:   "Unauthorized\n",
+			err:    "Failed to query Prometheus (401 Unauthorized): Unauthorized",
+		},
+		{
+			name:   "not json",
+			status: http.StatusOK,
+			body:   "<html></html>",
+			err:    "Failed to decode the response from Prometheus (200 OK)",
+		},
+		{
+			name:   "matrix",
+			status: http.StatusOK,
+			body:   `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+			err:    "Unexpected result type from Prometheus: matrix",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestPrometheus(t, tt.status, map[string]string{"up": tt.body})
+			samples, err := client.query("up")
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(samples) != tt.samples {
+				t.Errorf("got %v samples, want %v", len(samples), tt.samples)
+			}
+		})
+	}
+}
+
+func TestPrometheusGetPodMetricsList(t *testing.T) {
+	client := newTestPrometheus(t, http.StatusOK, map[string]string{
+		"container_cpu_usage_seconds_total": vector(
+			`"namespace":"default","pod":"a","container":"app"`, `"0.25"`,
+			`"namespace":"default","pod":"a","container":"sidecar"`, `"0.0015"`,
+			`"namespace":"default","pod":"b","container":"app"`, `"1"`,
+		),
+		"container_memory_working_set_bytes": vector(
+			`"namespace":"default","pod":"a","container":"app"`, `"1048576"`,
+		),
+	})
+	list, err := client.getPodMetricsList("default", labels.Everything())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 2 {
+		t.Fatalf("got %v pods, want 2", len(list.Items))
+	}
+	a := list.Items[0]
+	if a.Name != "a" || len(a.Containers) != 2 {
+		t.Fatalf("got pod %v with %v containers, want a with 2", a.Name, len(a.Containers))
+	}
+	if got := a.Containers[0].Usage.Cpu().MilliValue(); got != 250 {
+		t.Errorf("got cpu %vm, want 250m", got)
+	}
+	if got := a.Containers[1].Usage.Cpu().MilliValue(); got != 1 {
+		t.Errorf("got cpu %vm, want 1m", got)
+	}
+	if got := a.Containers[0].Usage.Memory().Value(); got != 1048576 {
+		t.Errorf("got memory %v, want 1048576", got)
+	}
+	// no memory sample
+	if _, ok := a.Containers[1].Usage["memory"]; ok {
+		t.Error("got memory of the container without samples")
+	}
+	if a.Timestamp.Unix() != 1551398400 {
+		t.Errorf("got timestamp %v", a.Timestamp)
+	}
+}
+
+func TestPrometheusGetPodMetricsListEmpty(t *testing.T) {
+	client := newTestPrometheus(t, http.StatusOK, map[string]string{
+		"container_cpu_usage_seconds_total":  vector(),
+		"container_memory_working_set_bytes": vector(),
+	})
+	list, err := client.getPodMetricsList("", labels.Everything())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 0 {
+		t.Errorf("got %v pods, want none", len(list.Items))
+	}
+}
+
+func TestPrometheusGetNodeMetricsList(t *testing.T) {
+	tests := []struct {
+		name   string
+		cpu    string
+		memory string
+		nodes  int
+		err    string
+	}{
+		{
+			name:   "nodes",
+			cpu:    vector(`"node":"a"`, `"1.5"`, `"node":"b"`, `"0.5"`),
+			memory: vector(`"node":"a"`, `"2147483648"`),
+			nodes:  2,
+		},
+		{
+			name:   "empty vectors",
+			cpu:    vector(),
+			memory: vector(),
+		},
+		{
+			name:   "malformed value",
+			cpu:    vector(`"node":"a"`, `"one"`),
+			memory: vector(),
+			err:    "Invalid value from Prometheus",
+		},
+		{
+			name:   "value not in string",
+			cpu:    vector(`"node":"a"`, `1`),
+			memory: vector(),
+			err:    "Unexpected value from Prometheus: 1",
+		},
+		{
+			name:   "missing node label",
+			cpu:    vector(`"instance":"10.0.0.1:10250"`, `"1"`),
+			memory: vector(),
+			err:    "Missing node label",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestPrometheus(t, http.StatusOK, map[string]string{
+				"container_cpu_usage_seconds_total":  tt.cpu,
+				"container_memory_working_set_bytes": tt.memory,
+			})
+			list, err := client.getNodeMetricsList(labels.Everything())
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list.Items) != tt.nodes {
+				t.Fatalf("got %v nodes, want %v", len(list.Items), tt.nodes)
+			}
+			if tt.nodes == 0 {
+				return
+			}
+			a := list.Items[0]
+			if got := a.Usage.Cpu().MilliValue(); a.Name != "a" || got != 1500 {
+				t.Errorf("got %v using %vm, want a using 1500m", a.Name, got)
+			}
+			if got := a.Usage.Memory().Value(); got != 2147483648 {
+				t.Errorf("got memory %v, want 2147483648", got)
+			}
+		})
+	}
+}
+
+func TestKubeClientsSelectPrometheusNodeMetrics(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for name, role := range map[string]string{"a": "master", "b": "worker"} {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"role": role}}}
+		if err := indexer.Add(node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	k := &KubeClients{
+		metricsClient: newTestPrometheus(t, http.StatusOK, map[string]string{
+			"container_cpu_usage_seconds_total":  vector(`"node":"a"`, `"1"`, `"node":"b"`, `"1"`),
+			"container_memory_working_set_bytes": vector(`"node":"a"`, `"1"`, `"node":"b"`, `"1"`),
+		}),
+		metricsBackend: PrometheusBackend,
+		nodeLister:     corev1lister.NewNodeLister(indexer),
+	}
+	list, err := k.GetNodeMetricsList(labels.SelectorFromSet(labels.Set{"role": "worker"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Name != "b" {
+		t.Errorf("got %v, want the metrics of node b only", list.Items)
+	}
+}
@@ -0,0 +1,149 @@
+package kube
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/metrics/pkg/apis/metrics"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// Frame is the objects fetched on a tick, before they are joined.
+type Frame struct {
+	Time     time.Time
+	NodeList *corev1.NodeList
+	PodList  *corev1.PodList
+	// pods on the nodes out of the pod scope, nil if PodList has them all
+	NodePodList *corev1.PodList
+	// true if the pods on the nodes are not listed, e.g. not allowed to
+	NodePodsUnknown bool
+	// replica sets to resolve the workloads of pods, nil if unknown
+	ReplicaSetList *appsv1.ReplicaSetList
+	// quotas of the namespaces, nil if unknown
+	ResourceQuotaList *corev1.ResourceQuotaList
+	NodeMetricsList   *metrics.NodeMetricsList
+	PodMetricsList    *metrics.PodMetricsList
+}
+
+// recordedFrame is a frame in a recording, which holds
+// the versioned metrics instead of the internal ones.
+type recordedFrame struct {
+	Time              time.Time                       `json:"time"`
+	NodeList          *corev1.NodeList                `json:"nodes"`
+	PodList           *corev1.PodList                 `json:"pods"`
+	NodePodList       *corev1.PodList                 `json:"nodePods,omitempty"`
+	NodePodsUnknown   bool                            `json:"nodePodsUnknown,omitempty"`
+	ReplicaSetList    *appsv1.ReplicaSetList          `json:"replicaSets,omitempty"`
+	ResourceQuotaList *corev1.ResourceQuotaList       `json:"resourceQuotas,omitempty"`
+	NodeMetricsList   *metricsv1beta1.NodeMetricsList `json:"nodeMetrics"`
+	PodMetricsList    *metricsv1beta1.PodMetricsList  `json:"podMetrics"`
+}
+
+// Recorder writes frames to a gzipped file, a JSON document per frame.
+type Recorder struct {
+	file    *os.File
+	writer  *gzip.Writer
+	encoder *json.Encoder
+}
+
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	writer := gzip.NewWriter(file)
+	return &Recorder{
+		file:    file,
+		writer:  writer,
+		encoder: json.NewEncoder(writer),
+	}, nil
+}
+
+// Record writes the frame, and flushes it
+// so that the recording is readable even if ktop is killed.
+func (r *Recorder) Record(frame *Frame) error {
+	recorded := &recordedFrame{
+		Time:              frame.Time,
+		NodeList:          frame.NodeList,
+		PodList:           frame.PodList,
+		NodePodList:       frame.NodePodList,
+		NodePodsUnknown:   frame.NodePodsUnknown,
+		ReplicaSetList:    frame.ReplicaSetList,
+		ResourceQuotaList: frame.ResourceQuotaList,
+		NodeMetricsList:   &metricsv1beta1.NodeMetricsList{},
+		PodMetricsList:    &metricsv1beta1.PodMetricsList{},
+	}
+	if err := metricsv1beta1.Convert_metrics_NodeMetricsList_To_v1beta1_NodeMetricsList(
+		frame.NodeMetricsList, recorded.NodeMetricsList, nil); err != nil {
+		return err
+	}
+	if err := metricsv1beta1.Convert_metrics_PodMetricsList_To_v1beta1_PodMetricsList(
+		frame.PodMetricsList, recorded.PodMetricsList, nil); err != nil {
+		return err
+	}
+	if err := r.encoder.Encode(recorded); err != nil {
+		return err
+	}
+	return r.writer.Flush()
+}
+
+func (r *Recorder) Close() error {
+	if err := r.writer.Close(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+// readFrames reads all frames in the recording.
+func readFrames(path string) ([]*Frame, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	frames := make([]*Frame, 0)
+	decoder := json.NewDecoder(reader)
+	for decoder.More() {
+		recorded := &recordedFrame{}
+		if err := decoder.Decode(recorded); err != nil {
+			// the recording was cut off, e.g. ktop was killed while writing
+			if err == io.ErrUnexpectedEOF && len(frames) > 0 {
+				break
+			}
+			return nil, err
+		}
+		frame := &Frame{
+			Time:              recorded.Time,
+			NodeList:          recorded.NodeList,
+			PodList:           recorded.PodList,
+			NodePodList:       recorded.NodePodList,
+			NodePodsUnknown:   recorded.NodePodsUnknown,
+			ReplicaSetList:    recorded.ReplicaSetList,
+			ResourceQuotaList: recorded.ResourceQuotaList,
+			NodeMetricsList:   &metrics.NodeMetricsList{},
+			PodMetricsList:    &metrics.PodMetricsList{},
+		}
+		if err := metricsv1beta1.Convert_v1beta1_NodeMetricsList_To_metrics_NodeMetricsList(
+			recorded.NodeMetricsList, frame.NodeMetricsList, nil); err != nil {
+			return nil, err
+		}
+		if err := metricsv1beta1.Convert_v1beta1_PodMetricsList_To_metrics_PodMetricsList(
+			recorded.PodMetricsList, frame.PodMetricsList, nil); err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
@@ -0,0 +1,145 @@
+package kube
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/metrics/pkg/apis/metrics"
+)
+
+var testStart = time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// testFrame returns a frame with a pod using the cpu in millicores.
+func testFrame(t time.Time, cpu int64) *Frame {
+	return &Frame{
+		Time: t,
+		NodeList: &corev1.NodeList{
+			Items: []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node"}}},
+		},
+		PodList: &corev1.PodList{
+			Items: []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod"}}},
+		},
+		NodeMetricsList: &metrics.NodeMetricsList{
+			Items: []metrics.NodeMetrics{{ObjectMeta: metav1.ObjectMeta{Name: "node"}}},
+		},
+		PodMetricsList: &metrics.PodMetricsList{
+			Items: []metrics.PodMetrics{{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod"},
+				Containers: []metrics.ContainerMetrics{{
+					Name: "container",
+					Usage: corev1.ResourceList{
+						corev1.ResourceCPU: *resource.NewMilliQuantity(cpu, resource.DecimalSI),
+					},
+				}},
+			}},
+		},
+	}
+}
+
+// record writes the frames without closing the recorder, as if ktop was killed,
+// and returns the sizes of the file after each frame.
+func record(t *testing.T, path string, frames ...*Frame) []int64 {
+	recorder, err := NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := make([]int64, len(frames))
+	for i, frame := range frames {
+		if err := recorder.Record(frame); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes[i] = info.Size()
+	}
+	return sizes
+}
+
+func TestRecordAndReadFrames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ktop.json.gz")
+	recorder, err := NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := []*Frame{
+		testFrame(testStart, 100),
+		testFrame(testStart.Add(time.Second), 200),
+	}
+	frames[1].NodePodsUnknown = true
+	for _, frame := range frames {
+		if err := recorder.Record(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readFrames(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(frames) {
+		t.Fatalf("got %v frames, want %v", len(got), len(frames))
+	}
+	for i, frame := range frames {
+		if !got[i].Time.Equal(frame.Time) {
+			t.Errorf("frame %v: got time %v, want %v", i, got[i].Time, frame.Time)
+		}
+		if got[i].NodePodsUnknown != frame.NodePodsUnknown {
+			t.Errorf("frame %v: got NodePodsUnknown %v, want %v", i, got[i].NodePodsUnknown, frame.NodePodsUnknown)
+		}
+		if n := len(got[i].PodList.Items); n != 1 {
+			t.Errorf("frame %v: got %v pods, want 1", i, n)
+		}
+		want := frame.PodMetricsList.Items[0].Containers[0].Usage.Cpu()
+		usage := got[i].PodMetricsList.Items[0].Containers[0].Usage.Cpu()
+		if usage.Cmp(*want) != 0 {
+			t.Errorf("frame %v: got cpu %v, want %v", i, usage, want)
+		}
+	}
+}
+
+func TestReadFramesTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ktop.json.gz")
+	sizes := record(t, path,
+		testFrame(testStart, 100),
+		testFrame(testStart.Add(time.Second), 200),
+	)
+
+	// without the gzip footer
+	got, err := readFrames(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Errorf("got %v frames without the footer, want 2", len(got))
+	}
+
+	// in the middle of the last frame
+	if err := os.Truncate(path, (sizes[0]+sizes[1])/2); err != nil {
+		t.Fatal(err)
+	}
+	got, err = readFrames(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Errorf("got %v frames cut off in the last one, want 1", len(got))
+	}
+
+	// in the middle of the first frame
+	if err := os.Truncate(path, sizes[0]/2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readFrames(path); err == nil {
+		t.Error("got no error cut off in the first frame")
+	}
+}
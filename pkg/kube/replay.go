@@ -0,0 +1,277 @@
+package kube
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	appsv1lister "k8s.io/client-go/listers/apps/v1"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/metrics/pkg/apis/metrics"
+)
+
+const (
+	minReplaySpeed = 1. / 16
+	maxReplaySpeed = 64.
+)
+
+// Player replays the frames of a recording in place of a cluster.
+// Pods and nodes of the current frame are served from the listers of KubeClients,
+// and their metrics from the metrics client.
+type Player struct {
+	mu     sync.Mutex
+	frames []*Frame
+	pos    int
+	paused bool
+	speed  float64
+	// elapsed time in the recording since the first frame
+	elapsed time.Duration
+
+	podFieldSelector     fields.Selector
+	podIndexer           cache.Indexer
+	nodeIndexer          cache.Indexer
+	nodePodIndexer       cache.Indexer
+	replicaSetIndexer    cache.Indexer
+	resourceQuotaIndexer cache.Indexer
+}
+
+func NewReplayClients(path string, flags *genericclioptions.ConfigFlags, scope Scope) (*KubeClients, *Player, error) {
+	parsed, err := scope.parse(*flags.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	frames, err := readFrames(path)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "Failed to read %v", path)
+	}
+	if len(frames) == 0 {
+		return nil, nil, errors.Errorf("No frames in %v", path)
+	}
+	player := &Player{
+		frames:           frames,
+		speed:            1,
+		podFieldSelector: parsed.podFieldSelector,
+		podIndexer: cache.NewIndexer(
+			cache.MetaNamespaceKeyFunc,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		),
+		nodeIndexer:    cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+		nodePodIndexer: cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+		replicaSetIndexer: cache.NewIndexer(
+			cache.MetaNamespaceKeyFunc,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		),
+		resourceQuotaIndexer: cache.NewIndexer(
+			cache.MetaNamespaceKeyFunc,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		),
+	}
+	if err := player.load(); err != nil {
+		return nil, nil, err
+	}
+	return &KubeClients{
+		Flags:          flags,
+		Namespaces:     parsed.namespaces,
+		PodSelector:    parsed.podSelector,
+		NodeSelector:   parsed.nodeSelector,
+		metricsClient:  &replayMetricsClient{player: player},
+		metricsBackend: ReplayBackend,
+		podListers: map[string]corev1lister.PodLister{
+			metav1.NamespaceAll: corev1lister.NewPodLister(player.podIndexer),
+		},
+		nodeLister:    corev1lister.NewNodeLister(player.nodeIndexer),
+		nodePodLister: corev1lister.NewPodLister(player.nodePodIndexer),
+		nodePodSynced: func() bool {
+			return !player.frame().NodePodsUnknown
+		},
+		// replica sets and quotas are unknown in the frames recorded without them
+		optionalListers: []*optionalListers{{
+			replicaSetLister: appsv1lister.NewReplicaSetLister(player.replicaSetIndexer),
+			replicaSetSynced: func() bool {
+				return player.frame().ReplicaSetList != nil
+			},
+			resourceQuotaLister: corev1lister.NewResourceQuotaLister(player.resourceQuotaIndexer),
+			resourceQuotaSynced: func() bool {
+				return player.frame().ResourceQuotaList != nil
+			},
+		}},
+		stopCh: make(chan struct{}),
+		now: func() time.Time {
+			return player.frame().Time
+		},
+	}, player, nil
+}
+
+// load fills the indexers with the pods and nodes of the current frame.
+func (p *Player) load() error {
+	frame := p.frames[p.pos]
+	pods := make([]interface{}, 0, len(frame.PodList.Items))
+	for i := range frame.PodList.Items {
+		pod := &frame.PodList.Items[i]
+		if p.podFieldSelector.Matches(podFields(pod)) {
+			pods = append(pods, pod)
+		}
+	}
+	if err := p.podIndexer.Replace(pods, ""); err != nil {
+		return err
+	}
+	// the pods in the scope on recording are all, unless the others are recorded
+	nodePodList := frame.NodePodList
+	if nodePodList == nil {
+		nodePodList = frame.PodList
+	}
+	nodePods := make([]interface{}, 0, len(nodePodList.Items))
+	for i := range nodePodList.Items {
+		nodePods = append(nodePods, &nodePodList.Items[i])
+	}
+	if err := p.nodePodIndexer.Replace(nodePods, ""); err != nil {
+		return err
+	}
+	replicaSets := make([]interface{}, 0)
+	if frame.ReplicaSetList != nil {
+		for i := range frame.ReplicaSetList.Items {
+			replicaSets = append(replicaSets, &frame.ReplicaSetList.Items[i])
+		}
+	}
+	if err := p.replicaSetIndexer.Replace(replicaSets, ""); err != nil {
+		return err
+	}
+	quotas := make([]interface{}, 0)
+	if frame.ResourceQuotaList != nil {
+		for i := range frame.ResourceQuotaList.Items {
+			quotas = append(quotas, &frame.ResourceQuotaList.Items[i])
+		}
+	}
+	if err := p.resourceQuotaIndexer.Replace(quotas, ""); err != nil {
+		return err
+	}
+	nodes := make([]interface{}, 0, len(frame.NodeList.Items))
+	for i := range frame.NodeList.Items {
+		nodes = append(nodes, &frame.NodeList.Items[i])
+	}
+	return p.nodeIndexer.Replace(nodes, "")
+}
+
+// podFields returns the fields of the pod which the API server
+// supports for field selectors.
+func podFields(pod *corev1.Pod) fields.Set {
+	return fields.Set{
+		"metadata.name":            pod.Name,
+		"metadata.namespace":       pod.Namespace,
+		"spec.nodeName":            pod.Spec.NodeName,
+		"spec.restartPolicy":       string(pod.Spec.RestartPolicy),
+		"spec.schedulerName":       pod.Spec.SchedulerName,
+		"spec.serviceAccountName":  pod.Spec.ServiceAccountName,
+		"status.phase":             string(pod.Status.Phase),
+		"status.podIP":             pod.Status.PodIP,
+		"status.nominatedNodeName": pod.Status.NominatedNodeName,
+	}
+}
+
+func (p *Player) seek(pos int) (bool, error) {
+	if pos == p.pos {
+		return false, nil
+	}
+	p.pos = pos
+	return true, p.load()
+}
+
+// Advance moves the replay forward by the duration scaled by the speed,
+// and reports whether it has moved to another frame.
+func (p *Player) Advance(d time.Duration) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused {
+		return false, nil
+	}
+	p.elapsed += time.Duration(float64(d) * p.speed)
+	start := p.frames[0].Time
+	pos := p.pos
+	for pos+1 < len(p.frames) && p.frames[pos+1].Time.Sub(start) <= p.elapsed {
+		pos++
+	}
+	return p.seek(pos)
+}
+
+// Step pauses the replay and moves to the next frame.
+func (p *Player) Step() (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = true
+	if p.pos+1 >= len(p.frames) {
+		return false, nil
+	}
+	p.elapsed = p.frames[p.pos+1].Time.Sub(p.frames[0].Time)
+	return p.seek(p.pos + 1)
+}
+
+func (p *Player) TogglePause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = !p.paused
+}
+
+func (p *Player) SpeedUp() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.speed*2 <= maxReplaySpeed {
+		p.speed *= 2
+	}
+}
+
+func (p *Player) SlowDown() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.speed/2 >= minReplaySpeed {
+		p.speed /= 2
+	}
+}
+
+func (p *Player) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	state := "playing"
+	if p.paused {
+		state = "paused"
+	}
+	return fmt.Sprintf("Replay %v/%v x%v %v\n%v",
+		p.pos+1, len(p.frames), p.speed, state,
+		p.frames[p.pos].Time.Format(time.RFC3339))
+}
+
+func (p *Player) frame() *Frame {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.frames[p.pos]
+}
+
+// replayMetricsClient serves the metrics of the current frame.
+// Label selectors are left to the listers, since metrics may not have labels,
+// and metrics of the objects out of the listers are dropped on joining.
+type replayMetricsClient struct {
+	player *Player
+}
+
+func (c *replayMetricsClient) getPodMetricsList(namespace string, labelSelector labels.Selector) (*metrics.PodMetricsList, error) {
+	list := &metrics.PodMetricsList{}
+	for _, item := range c.player.frame().PodMetricsList.Items {
+		if namespace == metav1.NamespaceAll || namespace == item.Namespace {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, nil
+}
+
+func (c *replayMetricsClient) getNodeMetricsList(labelSelector labels.Selector) (*metrics.NodeMetricsList, error) {
+	list := &metrics.NodeMetricsList{}
+	list.Items = append(list.Items, c.player.frame().NodeMetricsList.Items...)
+	return list, nil
+}
@@ -0,0 +1,239 @@
+package kube
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// newTestReplay replays the frames at 0s, 1s and 3s, using 100m, 200m and 300m of cpu.
+func newTestReplay(t *testing.T) (*KubeClients, *Player) {
+	path := filepath.Join(t.TempDir(), "ktop.json.gz")
+	record(t, path,
+		testFrame(testStart, 100),
+		testFrame(testStart.Add(time.Second), 200),
+		testFrame(testStart.Add(3*time.Second), 300),
+	)
+	clients, player, err := NewReplayClients(path, genericclioptions.NewConfigFlags(), Scope{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clients, player
+}
+
+// assertFrame checks that the clients serve the frame at the offset from the start.
+func assertFrame(t *testing.T, clients *KubeClients, offset time.Duration, cpu int64) {
+	t.Helper()
+	if got, want := clients.Now(), testStart.Add(offset); !got.Equal(want) {
+		t.Errorf("got time %v, want %v", got, want)
+	}
+	list, err := clients.GetPodMetricsList("default", labels.Everything())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := list.Items[0].Containers[0].Usage.Cpu().MilliValue(); got != cpu {
+		t.Errorf("got cpu %vm, want %vm", got, cpu)
+	}
+}
+
+func TestPlayerAdvance(t *testing.T) {
+	clients, player := newTestReplay(t)
+	assertFrame(t, clients, 0, 100)
+
+	tests := []struct {
+		d      time.Duration
+		moved  bool
+		offset time.Duration
+		cpu    int64
+	}{
+		{d: 500 * time.Millisecond, moved: false, offset: 0, cpu: 100},
+		{d: 500 * time.Millisecond, moved: true, offset: time.Second, cpu: 200},
+		{d: time.Second, moved: false, offset: time.Second, cpu: 200},
+		// past the end, it stays at the last frame
+		{d: time.Minute, moved: true, offset: 3 * time.Second, cpu: 300},
+		{d: time.Minute, moved: false, offset: 3 * time.Second, cpu: 300},
+	}
+	for i, tt := range tests {
+		moved, err := player.Advance(tt.d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if moved != tt.moved {
+			t.Errorf("%v: got moved %v, want %v", i, moved, tt.moved)
+		}
+		assertFrame(t, clients, tt.offset, tt.cpu)
+	}
+}
+
+func TestPlayerPause(t *testing.T) {
+	clients, player := newTestReplay(t)
+	player.TogglePause()
+	if moved, _ := player.Advance(time.Minute); moved {
+		t.Error("got moved on pause")
+	}
+	assertFrame(t, clients, 0, 100)
+	player.TogglePause()
+	if moved, _ := player.Advance(time.Second); !moved {
+		t.Error("got not moved on resume")
+	}
+	assertFrame(t, clients, time.Second, 200)
+}
+
+func TestPlayerStep(t *testing.T) {
+	clients, player := newTestReplay(t)
+	if moved, _ := player.Step(); !moved {
+		t.Error("got not moved on the first step")
+	}
+	assertFrame(t, clients, time.Second, 200)
+	// the step pauses the replay
+	if moved, _ := player.Advance(time.Minute); moved {
+		t.Error("got moved after the step")
+	}
+	// and it resumes from the frame stepped to
+	player.TogglePause()
+	if moved, _ := player.Advance(time.Second); moved {
+		t.Error("got moved before the next frame")
+	}
+	if moved, _ := player.Advance(time.Second); !moved {
+		t.Error("got not moved at the next frame")
+	}
+	assertFrame(t, clients, 3*time.Second, 300)
+	if moved, _ := player.Step(); moved {
+		t.Error("got moved at the last frame")
+	}
+	assertFrame(t, clients, 3*time.Second, 300)
+}
+
+func TestPlayerSpeed(t *testing.T) {
+	clients, player := newTestReplay(t)
+	player.SpeedUp()
+	if moved, _ := player.Advance(500 * time.Millisecond); !moved {
+		t.Error("got not moved at x2")
+	}
+	assertFrame(t, clients, time.Second, 200)
+	player.SlowDown()
+	player.SlowDown()
+	if moved, _ := player.Advance(3 * time.Second); moved {
+		t.Error("got moved at x0.5 before the next frame")
+	}
+	if moved, _ := player.Advance(time.Second); !moved {
+		t.Error("got not moved at x0.5 at the next frame")
+	}
+	assertFrame(t, clients, 3*time.Second, 300)
+
+	for i := 0; i < 20; i++ {
+		player.SpeedUp()
+	}
+	if player.speed != maxReplaySpeed {
+		t.Errorf("got speed %v, want %v at most", player.speed, maxReplaySpeed)
+	}
+	for i := 0; i < 20; i++ {
+		player.SlowDown()
+	}
+	if player.speed != minReplaySpeed {
+		t.Errorf("got speed %v, want %v at least", player.speed, minReplaySpeed)
+	}
+}
+
+func TestReplayReplicaSets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ktop.json.gz")
+	known := testFrame(testStart.Add(time.Second), 200)
+	known.ReplicaSetList = &appsv1.ReplicaSetList{
+		Items: []appsv1.ReplicaSet{{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "rs"}}},
+	}
+	record(t, path, testFrame(testStart, 100), known)
+	clients, player, err := NewReplayClients(path, genericclioptions.NewConfigFlags(), Scope{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// recorded without them
+	list, err := clients.GetReplicaSetList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list != nil {
+		t.Errorf("got %v replica sets, want unknown", len(list.Items))
+	}
+
+	if _, err := player.Step(); err != nil {
+		t.Fatal(err)
+	}
+	list, err = clients.GetReplicaSetList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list == nil || len(list.Items) != 1 {
+		t.Errorf("got %v, want the replica set recorded", list)
+	}
+}
+
+func TestReplayResourceQuotas(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ktop.json.gz")
+	known := testFrame(testStart.Add(time.Second), 200)
+	known.ResourceQuotaList = &corev1.ResourceQuotaList{
+		Items: []corev1.ResourceQuota{{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "quota"}}},
+	}
+	record(t, path, testFrame(testStart, 100), known)
+	clients, player, err := NewReplayClients(path, genericclioptions.NewConfigFlags(), Scope{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// recorded without them
+	list, err := clients.GetResourceQuotaList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list != nil {
+		t.Errorf("got %v quotas, want unknown", len(list.Items))
+	}
+
+	if _, err := player.Step(); err != nil {
+		t.Fatal(err)
+	}
+	list, err = clients.GetResourceQuotaList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list == nil || len(list.Items) != 1 {
+		t.Errorf("got %v, want the quota recorded", list)
+	}
+}
+
+func TestReplayNodePods(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ktop.json.gz")
+	unknown := testFrame(testStart, 100)
+	unknown.NodePodsUnknown = true
+	known := testFrame(testStart.Add(time.Second), 200)
+	known.NodePodList = &corev1.PodList{
+		Items: []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "pod"}}},
+	}
+	record(t, path, unknown, known)
+	clients, player, err := NewReplayClients(path, genericclioptions.NewConfigFlags(), Scope{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// not allowed to list them on recording
+	if _, synced, err := clients.GetNodePodList(); err != nil || synced {
+		t.Errorf("got synced %v (%v), want unknown", synced, err)
+	}
+
+	if _, err := player.Step(); err != nil {
+		t.Fatal(err)
+	}
+	list, synced, err := clients.GetNodePodList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !synced || len(list.Items) != 1 || list.Items[0].Namespace != "other" {
+		t.Errorf("got %v, want the pod recorded on the node", list)
+	}
+}
@@ -0,0 +1,54 @@
+package resource
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	. "github.com/ynqa/ktop/pkg/util"
+)
+
+// extraResourceNames returns the nonzero resources other than cpu and memory in any of the lists.
+func extraResourceNames(lists ...corev1.ResourceList) []corev1.ResourceName {
+	seen := make(map[corev1.ResourceName]bool)
+	names := make([]corev1.ResourceName, 0)
+	for _, lst := range lists {
+		for name, q := range lst {
+			if !seen[name] && IsExtraResourceName(name) && !q.IsZero() {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// extraHeader returns the columns of the resources, e.g. nvidia.com/gpu(R).
+func extraHeader(names []corev1.ResourceName, suffixes ...string) []string {
+	header := make([]string, 0, len(names)*len(suffixes))
+	for _, name := range names {
+		for _, suffix := range suffixes {
+			header = append(header, string(name)+suffix)
+		}
+	}
+	return header
+}
+
+// extraWidths fits the columns to their names.
+func extraWidths(header []string) []int {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = IntMax(10, len(h)+2)
+	}
+	return widths
+}
+
+// withExtra returns the columns of the table followed by the ones of the resources.
+func withExtra(columns []string, extra []string) []string {
+	joined := make([]string, 0, len(columns)+len(extra))
+	joined = append(joined, columns...)
+	return append(joined, extra...)
+}
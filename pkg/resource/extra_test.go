@@ -0,0 +1,54 @@
+package resource
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestExtraResourceNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		lists []corev1.ResourceList
+		want  []corev1.ResourceName
+	}{
+		{
+			name: "none",
+			want: []corev1.ResourceName{},
+		},
+		{
+			name:  "cpu and memory only",
+			lists: []corev1.ResourceList{resourceList("cpu", "1", "memory", "1Gi", "pods", "110")},
+			want:  []corev1.ResourceName{},
+		},
+		{
+			name: "sorted across the lists",
+			lists: []corev1.ResourceList{
+				resourceList("nvidia.com/gpu", "2", "cpu", "1"),
+				resourceList("ephemeral-storage", "10Gi", "nvidia.com/gpu", "1"),
+			},
+			want: []corev1.ResourceName{"ephemeral-storage", "nvidia.com/gpu"},
+		},
+		{
+			name: "zero everywhere",
+			lists: []corev1.ResourceList{
+				resourceList("hugepages-2Mi", "0", "hugepages-1Gi", "0"),
+				resourceList("hugepages-2Mi", "0", "hugepages-1Gi", "2Gi"),
+			},
+			want: []corev1.ResourceName{"hugepages-1Gi"},
+		},
+		{
+			name:  "quotas left",
+			lists: []corev1.ResourceList{resourceList("requests.nvidia.com/gpu", "2")},
+			want:  []corev1.ResourceName{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extraResourceNames(tt.lists...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package resource
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	. "github.com/ynqa/ktop/pkg/util"
+)
+
+const (
+	// group of the pods without the label
+	noneGroup = "<none>"
+)
+
+// GroupResource sums up the pods which have the same value of a label.
+type GroupResource struct {
+	key      string
+	value    string
+	pods     []*SummarizedResource
+	usage    corev1.ResourceList
+	requests corev1.ResourceList
+	limits   corev1.ResourceList
+}
+
+// NewGroupResources groups the pods by the values of the label across namespaces, e.g. team.
+func NewGroupResources(pods []*SummarizedResource, key string) []*GroupResource {
+	values, grouped := groupPods(pods, func(p *SummarizedResource) string {
+		if value, ok := p.pod.pod.Labels[key]; ok {
+			return value
+		}
+		return noneGroup
+	})
+	resources := make([]*GroupResource, len(values))
+	for i, value := range values {
+		usage, requests, limits := sumPods(grouped[value])
+		resources[i] = &GroupResource{
+			key:      key,
+			value:    value,
+			pods:     grouped[value],
+			usage:    usage,
+			requests: requests,
+			limits:   limits,
+		}
+	}
+	return resources
+}
+
+// groupPods groups the pods by the keys of them, which are in order of appearance.
+func groupPods(pods []*SummarizedResource, keyFn func(*SummarizedResource) string) ([]string, map[string][]*SummarizedResource) {
+	keys := make([]string, 0)
+	grouped := make(map[string][]*SummarizedResource)
+	for _, pod := range pods {
+		key := keyFn(pod)
+		if _, ok := grouped[key]; !ok {
+			keys = append(keys, key)
+		}
+		grouped[key] = append(grouped[key], pod)
+	}
+	return keys, grouped
+}
+
+// sumPods sums the usage, the requests and the limits of the pods, which must all have limits.
+func sumPods(pods []*SummarizedResource) (corev1.ResourceList, corev1.ResourceList, corev1.ResourceList) {
+	usages := make([]corev1.ResourceList, len(pods))
+	requests := make([]corev1.ResourceList, len(pods))
+	limits := make([]corev1.ResourceList, len(pods))
+	for i, p := range pods {
+		usages[i], requests[i], limits[i] = p.usage, p.requests, p.limits
+	}
+	return sumResourceLists(usages, false), sumResourceLists(requests, false), sumResourceLists(limits, true)
+}
+
+// GetGroupName returns the label and the value, e.g. team=payments.
+func (g *GroupResource) GetGroupName() string {
+	return g.key + "=" + g.value
+}
+
+func (g *GroupResource) GetValue() string {
+	return g.value
+}
+
+func (g *GroupResource) GetPods() int {
+	return len(g.pods)
+}
+
+func (g *GroupResource) GetCpuUsage() (float64, string) {
+	return GetResourceValue(g.usage, corev1.ResourceCPU),
+		GetResourceValueString(g.usage, corev1.ResourceCPU)
+}
+
+func (g *GroupResource) GetMemoryUsage() (float64, string) {
+	return GetResourceValue(g.usage, corev1.ResourceMemory),
+		GetResourceValueString(g.usage, corev1.ResourceMemory)
+}
+
+func (g *GroupResource) GetCpuRequests() (float64, string, bool) {
+	return resourceValueOf(g.requests, corev1.ResourceCPU)
+}
+
+func (g *GroupResource) GetMemoryRequests() (float64, string, bool) {
+	return resourceValueOf(g.requests, corev1.ResourceMemory)
+}
+
+func (g *GroupResource) GetCpuLimits() (float64, string, bool) {
+	return resourceValueOf(g.limits, corev1.ResourceCPU)
+}
+
+func (g *GroupResource) GetMemoryLimits() (float64, string, bool) {
+	return resourceValueOf(g.limits, corev1.ResourceMemory)
+}
+
+// Key identifies the group by the label and the value.
+func (g *GroupResource) Key() string {
+	return "group/" + g.key + "/" + g.value
+}
+
+func (g *GroupResource) names() []string {
+	return []string{g.value}
+}
+
+func (g *GroupResource) sortValue(sortType SortType) float64 {
+	switch sortType {
+	case ByPods:
+		return float64(len(g.pods))
+	case ByCpuUsage:
+		return GetResourceValue(g.usage, corev1.ResourceCPU)
+	case ByCpuRequests:
+		return GetResourceValue(g.requests, corev1.ResourceCPU)
+	case ByCpuLimits:
+		return GetResourceValue(g.limits, corev1.ResourceCPU)
+	case ByCpuRequestPercentage:
+		return percentage(g.usage, g.requests, corev1.ResourceCPU)
+	case ByCpuLimitPercentage:
+		return percentage(g.usage, g.limits, corev1.ResourceCPU)
+	case ByMemoryUsage:
+		return GetResourceValue(g.usage, corev1.ResourceMemory)
+	case ByMemoryRequests:
+		return GetResourceValue(g.requests, corev1.ResourceMemory)
+	case ByMemoryLimits:
+		return GetResourceValue(g.limits, corev1.ResourceMemory)
+	case ByMemoryRequestPercentage:
+		return percentage(g.usage, g.requests, corev1.ResourceMemory)
+	case ByMemoryLimitPercentage:
+		return percentage(g.usage, g.limits, corev1.ResourceMemory)
+	default:
+		return 0
+	}
+}
+
+// levels colors the percentages of the limits.
+func (g *GroupResource) levels(t Thresholds) []Level {
+	return t.usageLevels(len(groupHeader), g.usage, g.limits, 6, 11)
+}
+
+// Level returns how close the usage is to the limits.
+func (g *GroupResource) Level(t Thresholds) Level {
+	return maxLevel(g.levels(t))
+}
+
+// header: "GROUP", "PODS",
+// "CPU(U)", "CPU(R)", "CPU(L)", "%CPU(R)", "%CPU(L)",
+// "Memory(U)", "Memory(R)", "Memory(L)", "%Memory(R)", "%Memory(L)"
+func (g *GroupResource) toRow() []string {
+	return []string{
+		g.value,
+		fmt.Sprint(len(g.pods)),
+		GetResourceValueString(g.usage, corev1.ResourceCPU),
+		GetResourceValueString(g.requests, corev1.ResourceCPU),
+		GetResourceValueString(g.limits, corev1.ResourceCPU),
+		percentageString(g.usage, g.requests, corev1.ResourceCPU),
+		percentageString(g.usage, g.limits, corev1.ResourceCPU),
+		GetResourceValueString(g.usage, corev1.ResourceMemory),
+		GetResourceValueString(g.requests, corev1.ResourceMemory),
+		GetResourceValueString(g.limits, corev1.ResourceMemory),
+		percentageString(g.usage, g.requests, corev1.ResourceMemory),
+		percentageString(g.usage, g.limits, corev1.ResourceMemory),
+	}
+}
+
+// Describe shows the group with its pods.
+func (g *GroupResource) Describe(now time.Time) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Label: %v\n", g.key)
+	fmt.Fprintf(&buf, "Value: %v\n", g.value)
+	fmt.Fprintf(&buf, "Pods: %v\n", len(g.pods))
+	buf.WriteString("\n")
+
+	pods := make([]*SummarizedResource, len(g.pods))
+	copy(pods, g.pods)
+	sort.Slice(pods, func(i, j int) bool {
+		return lessNames(pods[i].names(), pods[j].names())
+	})
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join([]string{
+		"NAMESPACE", "POD", "NODE", "AGE", "CPU(U)", "Memory(U)",
+	}, "\t"))
+	for _, p := range pods {
+		fmt.Fprintln(w, strings.Join([]string{
+			p.namespace,
+			p.podName,
+			orNone(p.nodeName),
+			age(p.pod.pod.CreationTimestamp.Time, now),
+			GetResourceValueString(p.usage, corev1.ResourceCPU),
+			GetResourceValueString(p.usage, corev1.ResourceMemory),
+		}, "\t"))
+	}
+	w.Flush()
+	return buf.String()
+}
@@ -0,0 +1,94 @@
+package resource
+
+import (
+	"image"
+	"sort"
+
+	. "github.com/ynqa/ktop/pkg/util"
+)
+
+var (
+	groupTitle  = "⎈ Group ⎈"
+	groupHeader = []string{
+		"GROUP", "PODS",
+		"CPU(U)", "CPU(R)", "CPU(L)", "%CPU(R)", "%CPU(L)",
+		"Memory(U)", "Memory(R)", "Memory(L)", "%Memory(R)", "%Memory(L)",
+	}
+	groupWidthFn = func(rect image.Rectangle, maxLen int) []int {
+		nameWidth := IntMax(30, IntMin(rect.Dx()-115, maxLen+indentSize))
+		return []int{nameWidth, 8, 10, 10, 10, 10, 10, 10, 10, 10, 11, 11}
+	}
+	groupSortColumns = []sortColumn{
+		{ByName, 0}, {ByPods, 1},
+		{ByCpuUsage, 2}, {ByCpuRequests, 3}, {ByCpuLimits, 4},
+		{ByCpuRequestPercentage, 5}, {ByCpuLimitPercentage, 6},
+		{ByMemoryUsage, 7}, {ByMemoryRequests, 8}, {ByMemoryLimits, 9},
+		{ByMemoryRequestPercentage, 10}, {ByMemoryLimitPercentage, 11},
+	}
+)
+
+// AsGroupTableViewer shows the groups by the values of the label of the key.
+func AsGroupTableViewer(resources []*GroupResource, key string, sortType SortType, reverse bool) ResourceTableViewer {
+	return &groupTableViewer{
+		resources: resources,
+		key:       key,
+		sortType:  sortType,
+		reverse:   reverse,
+	}
+}
+
+type groupTableViewer struct {
+	resources []*GroupResource
+	key       string
+	sortType  SortType
+	reverse   bool
+}
+
+func (s *groupTableViewer) GetTableShape(rect image.Rectangle) (string, []string, []int, [][]string) {
+	rows := make([][]string, len(s.resources))
+	var maxLen int
+	for i, v := range s.resources {
+		rows[i] = v.toRow()
+		maxLen = IntMax(maxLen, len(rows[i][0]))
+	}
+	title, header, widths :=
+		"⎈ Group by "+s.key+" ⎈",
+		markHeader(groupHeader, groupSortColumns, s.sortType, s.reverse),
+		groupWidthFn(rect, maxLen)
+
+	if len(s.resources) == 0 {
+		header = emptyHeader
+		widths = emptyWidthFn(rect)
+		rows = emptyRows
+	}
+	return title, header, widths, rows
+}
+
+func (s *groupTableViewer) GetHeader() []string {
+	return groupHeader
+}
+
+func (s *groupTableViewer) GetRows() [][]string {
+	rows := make([][]string, len(s.resources))
+	for i, v := range s.resources {
+		rows[i] = v.toRow()
+	}
+	return rows
+}
+
+func (s *groupTableViewer) GetLevels(t Thresholds) [][]Level {
+	levels := make([][]Level, len(s.resources))
+	for i, v := range s.resources {
+		levels[i] = v.levels(t)
+	}
+	return levels
+}
+
+func (s *groupTableViewer) SortRows() {
+	sort.SliceStable(s.resources, func(i, j int) bool {
+		x, y := s.resources[i], s.resources[j]
+		return lessBy(s.sortType, s.reverse,
+			x.sortValue(s.sortType), y.sortValue(s.sortType),
+			x.names(), y.names())
+	})
+}
@@ -0,0 +1,77 @@
+package resource
+
+import (
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Level tells how close the usage is to the limit.
+type Level int
+
+const (
+	Normal Level = iota
+	Warning
+	Critical
+)
+
+func (l Level) String() string {
+	switch l {
+	case Warning:
+		return "warning"
+	case Critical:
+		return "critical"
+	default:
+		return "normal"
+	}
+}
+
+// Thresholds are the percentages of usage to warn about,
+// of the limits for pods, containers and workloads, of the allocatable for nodes,
+// and of the quotas for namespaces.
+// A threshold of 0 is disabled.
+type Thresholds struct {
+	Warning  float64
+	Critical float64
+}
+
+func (t Thresholds) Validate() error {
+	if t.Warning < 0 || t.Critical < 0 {
+		return errors.Errorf("Invalid thresholds: warning %v, critical %v", t.Warning, t.Critical)
+	}
+	if t.Warning > 0 && t.Critical > 0 && t.Warning > t.Critical {
+		return errors.Errorf("Warning threshold %v is above critical %v", t.Warning, t.Critical)
+	}
+	return nil
+}
+
+func (t Thresholds) levelOf(percentage float64) Level {
+	percentage = finiteOrZero(percentage)
+	switch {
+	case t.Critical > 0 && percentage >= t.Critical:
+		return Critical
+	case t.Warning > 0 && percentage >= t.Warning:
+		return Warning
+	default:
+		return Normal
+	}
+}
+
+// usageLevels returns the levels of the cells in a row, where the percentages
+// of cpu and memory usage are in the columns.
+func (t Thresholds) usageLevels(columns int, usage, limits corev1.ResourceList, cpuColumn, memColumn int) []Level {
+	levels := make([]Level, columns)
+	levels[cpuColumn] = t.levelOf(percentage(usage, limits, corev1.ResourceCPU))
+	levels[memColumn] = t.levelOf(percentage(usage, limits, corev1.ResourceMemory))
+	return levels
+}
+
+// maxLevel returns the highest level among the cells, which is the one of the row.
+func maxLevel(levels []Level) Level {
+	max := Normal
+	for _, l := range levels {
+		if l > max {
+			max = l
+		}
+	}
+	return max
+}
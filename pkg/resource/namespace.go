@@ -0,0 +1,282 @@
+package resource
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kr "k8s.io/apimachinery/pkg/api/resource"
+
+	. "github.com/ynqa/ktop/pkg/util"
+)
+
+// NamespaceResource sums up the pods in a namespace, with its quotas.
+type NamespaceResource struct {
+	namespace string
+	pods      []*SummarizedResource
+	usage     corev1.ResourceList
+	requests  corev1.ResourceList
+	limits    corev1.ResourceList
+
+	quotas []corev1.ResourceQuota
+	// hard and used of the quotas on the requests and the limits
+	quotaRequests     corev1.ResourceList
+	quotaLimits       corev1.ResourceList
+	usedQuotaRequests corev1.ResourceList
+	usedQuotaLimits   corev1.ResourceList
+}
+
+// NewNamespaceResources groups the pods by their namespaces. Quotas may be nil if unknown.
+func NewNamespaceResources(pods []*SummarizedResource, quotas *corev1.ResourceQuotaList) []*NamespaceResource {
+	quotasByNamespace := make(map[string][]corev1.ResourceQuota)
+	if quotas != nil {
+		for _, quota := range quotas.Items {
+			quotasByNamespace[quota.Namespace] = append(quotasByNamespace[quota.Namespace], quota)
+		}
+	}
+	namespaces, grouped := groupPods(pods, func(p *SummarizedResource) string {
+		return p.namespace
+	})
+	resources := make([]*NamespaceResource, len(namespaces))
+	for i, namespace := range namespaces {
+		resources[i] = newNamespaceResource(namespace, grouped[namespace], quotasByNamespace[namespace])
+	}
+	return resources
+}
+
+func newNamespaceResource(namespace string, pods []*SummarizedResource, quotas []corev1.ResourceQuota) *NamespaceResource {
+	usage, requests, limits := sumPods(pods)
+	hard, used := tightestQuota(quotas)
+	quotaRequests, quotaLimits := splitQuota(hard)
+	usedQuotaRequests, usedQuotaLimits := splitQuota(used)
+	return &NamespaceResource{
+		namespace:         namespace,
+		pods:              pods,
+		usage:             usage,
+		requests:          requests,
+		limits:            limits,
+		quotas:            quotas,
+		quotaRequests:     quotaRequests,
+		quotaLimits:       quotaLimits,
+		usedQuotaRequests: usedQuotaRequests,
+		usedQuotaLimits:   usedQuotaLimits,
+	}
+}
+
+// tightestQuota returns the smallest hard of the unscoped quotas for each resource, and the used of it.
+func tightestQuota(quotas []corev1.ResourceQuota) (corev1.ResourceList, corev1.ResourceList) {
+	hard, used := corev1.ResourceList{}, corev1.ResourceList{}
+	for _, quota := range quotas {
+		if len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil {
+			continue
+		}
+		for name, q := range quota.Spec.Hard {
+			if current, ok := hard[name]; ok && q.Cmp(current) >= 0 {
+				continue
+			}
+			hard[name] = q
+			used[name] = quota.Status.Used[name]
+		}
+	}
+	return hard, used
+}
+
+// splitQuota returns the quota on the requests, e.g. requests.cpu or cpu, and the one on the limits.
+func splitQuota(lst corev1.ResourceList) (corev1.ResourceList, corev1.ResourceList) {
+	requests, limits := corev1.ResourceList{}, corev1.ResourceList{}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if q, ok := lst["requests."+name]; ok {
+			requests[name] = q
+		} else if q, ok := lst[name]; ok {
+			requests[name] = q
+		}
+		if q, ok := lst["limits."+name]; ok {
+			limits[name] = q
+		}
+	}
+	return requests, limits
+}
+
+func (n *NamespaceResource) GetNamespace() string {
+	return n.namespace
+}
+
+func (n *NamespaceResource) GetPods() int {
+	return len(n.pods)
+}
+
+func (n *NamespaceResource) GetCpuUsage() (float64, string) {
+	return GetResourceValue(n.usage, corev1.ResourceCPU),
+		GetResourceValueString(n.usage, corev1.ResourceCPU)
+}
+
+func (n *NamespaceResource) GetMemoryUsage() (float64, string) {
+	return GetResourceValue(n.usage, corev1.ResourceMemory),
+		GetResourceValueString(n.usage, corev1.ResourceMemory)
+}
+
+func (n *NamespaceResource) GetCpuRequests() (float64, string, bool) {
+	return resourceValueOf(n.requests, corev1.ResourceCPU)
+}
+
+func (n *NamespaceResource) GetMemoryRequests() (float64, string, bool) {
+	return resourceValueOf(n.requests, corev1.ResourceMemory)
+}
+
+func (n *NamespaceResource) GetCpuLimits() (float64, string, bool) {
+	return resourceValueOf(n.limits, corev1.ResourceCPU)
+}
+
+func (n *NamespaceResource) GetMemoryLimits() (float64, string, bool) {
+	return resourceValueOf(n.limits, corev1.ResourceMemory)
+}
+
+func (n *NamespaceResource) GetCpuQuotaRequests() (float64, string, bool) {
+	return resourceValueOf(n.quotaRequests, corev1.ResourceCPU)
+}
+
+func (n *NamespaceResource) GetMemoryQuotaRequests() (float64, string, bool) {
+	return resourceValueOf(n.quotaRequests, corev1.ResourceMemory)
+}
+
+func (n *NamespaceResource) GetCpuQuotaLimits() (float64, string, bool) {
+	return resourceValueOf(n.quotaLimits, corev1.ResourceCPU)
+}
+
+func (n *NamespaceResource) GetMemoryQuotaLimits() (float64, string, bool) {
+	return resourceValueOf(n.quotaLimits, corev1.ResourceMemory)
+}
+
+// resourceValueOf returns the value of the resource, and whether the list has it.
+func resourceValueOf(lst corev1.ResourceList, name corev1.ResourceName) (float64, string, bool) {
+	_, ok := lst[name]
+	return GetResourceValue(lst, name), GetResourceValueString(lst, name), ok
+}
+
+// Key identifies the namespace.
+func (n *NamespaceResource) Key() string {
+	return "namespace/" + n.namespace
+}
+
+func (n *NamespaceResource) names() []string {
+	return []string{n.namespace}
+}
+
+func (n *NamespaceResource) sortValue(sortType SortType) float64 {
+	switch sortType {
+	case ByPods:
+		return float64(len(n.pods))
+	case ByCpuUsage:
+		return GetResourceValue(n.usage, corev1.ResourceCPU)
+	case ByCpuRequests:
+		return GetResourceValue(n.requests, corev1.ResourceCPU)
+	case ByCpuLimits:
+		return GetResourceValue(n.limits, corev1.ResourceCPU)
+	case ByCpuRequestQuotaPercentage:
+		return percentage(n.usedQuotaRequests, n.quotaRequests, corev1.ResourceCPU)
+	case ByCpuLimitQuotaPercentage:
+		return percentage(n.usedQuotaLimits, n.quotaLimits, corev1.ResourceCPU)
+	case ByMemoryUsage:
+		return GetResourceValue(n.usage, corev1.ResourceMemory)
+	case ByMemoryRequests:
+		return GetResourceValue(n.requests, corev1.ResourceMemory)
+	case ByMemoryLimits:
+		return GetResourceValue(n.limits, corev1.ResourceMemory)
+	case ByMemoryRequestQuotaPercentage:
+		return percentage(n.usedQuotaRequests, n.quotaRequests, corev1.ResourceMemory)
+	case ByMemoryLimitQuotaPercentage:
+		return percentage(n.usedQuotaLimits, n.quotaLimits, corev1.ResourceMemory)
+	default:
+		return 0
+	}
+}
+
+// levels colors the percentages of the quotas.
+func (n *NamespaceResource) levels(t Thresholds) []Level {
+	levels := make([]Level, len(namespaceHeader))
+	levels[7] = t.levelOf(percentage(n.usedQuotaRequests, n.quotaRequests, corev1.ResourceCPU))
+	levels[8] = t.levelOf(percentage(n.usedQuotaLimits, n.quotaLimits, corev1.ResourceCPU))
+	levels[14] = t.levelOf(percentage(n.usedQuotaRequests, n.quotaRequests, corev1.ResourceMemory))
+	levels[15] = t.levelOf(percentage(n.usedQuotaLimits, n.quotaLimits, corev1.ResourceMemory))
+	return levels
+}
+
+// Level returns how close the namespace is to the quotas.
+func (n *NamespaceResource) Level(t Thresholds) Level {
+	return maxLevel(n.levels(t))
+}
+
+// header: "NAMESPACE", "PODS",
+// "CPU(U)", "CPU(R)", "CPU(L)", "CPU(QR)", "CPU(QL)", "%CPU(QR)", "%CPU(QL)",
+// "Memory(U)", "Memory(R)", "Memory(L)", "Memory(QR)", "Memory(QL)", "%Memory(QR)", "%Memory(QL)"
+func (n *NamespaceResource) toRow() []string {
+	return []string{
+		n.namespace,
+		fmt.Sprint(len(n.pods)),
+		GetResourceValueString(n.usage, corev1.ResourceCPU),
+		GetResourceValueString(n.requests, corev1.ResourceCPU),
+		GetResourceValueString(n.limits, corev1.ResourceCPU),
+		GetResourceValueString(n.quotaRequests, corev1.ResourceCPU),
+		GetResourceValueString(n.quotaLimits, corev1.ResourceCPU),
+		percentageString(n.usedQuotaRequests, n.quotaRequests, corev1.ResourceCPU),
+		percentageString(n.usedQuotaLimits, n.quotaLimits, corev1.ResourceCPU),
+		GetResourceValueString(n.usage, corev1.ResourceMemory),
+		GetResourceValueString(n.requests, corev1.ResourceMemory),
+		GetResourceValueString(n.limits, corev1.ResourceMemory),
+		GetResourceValueString(n.quotaRequests, corev1.ResourceMemory),
+		GetResourceValueString(n.quotaLimits, corev1.ResourceMemory),
+		percentageString(n.usedQuotaRequests, n.quotaRequests, corev1.ResourceMemory),
+		percentageString(n.usedQuotaLimits, n.quotaLimits, corev1.ResourceMemory),
+	}
+}
+
+// Describe shows the namespace with all its quotas.
+func (n *NamespaceResource) Describe(now time.Time) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Name: %v\n", n.namespace)
+	fmt.Fprintf(&buf, "Pods: %v\n", len(n.pods))
+	buf.WriteString("\n")
+
+	if len(n.quotas) == 0 {
+		buf.WriteString("No resource quotas\n")
+		return buf.String()
+	}
+	quotas := make([]corev1.ResourceQuota, len(n.quotas))
+	copy(quotas, n.quotas)
+	sort.Slice(quotas, func(i, j int) bool {
+		return quotas[i].Name < quotas[j].Name
+	})
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join([]string{"QUOTA", "RESOURCE", "USED", "HARD", "%USED", "AGE"}, "\t"))
+	for _, quota := range quotas {
+		name := quota.Name
+		if len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil {
+			name += " (scoped)"
+		}
+		for _, rn := range resourceNames(quota.Spec.Hard) {
+			hard, used := quota.Spec.Hard[rn], quota.Status.Used[rn]
+			fmt.Fprintln(w, strings.Join([]string{
+				name,
+				string(rn),
+				quantityString(quota.Status.Used, rn),
+				quantityString(quota.Spec.Hard, rn),
+				quotaPercentageString(used, hard),
+				age(quota.CreationTimestamp.Time, now),
+			}, "\t"))
+		}
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// quotaPercentageString works for any resource, e.g. the number of pods, unlike percentageString.
+func quotaPercentageString(used, hard kr.Quantity) string {
+	if hard.IsZero() {
+		return "-"
+	}
+	return GetResourcePercentageString(used, hard)
+}
@@ -0,0 +1,140 @@
+package resource
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	kr "k8s.io/apimachinery/pkg/api/resource"
+)
+
+// resourceList parses the pairs of the names and the quantities.
+func resourceList(pairs ...string) corev1.ResourceList {
+	lst := corev1.ResourceList{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		lst[corev1.ResourceName(pairs[i])] = kr.MustParse(pairs[i+1])
+	}
+	return lst
+}
+
+// assertResourceList checks the quantities of the list, ignoring the formats.
+func assertResourceList(t *testing.T, what string, got, want corev1.ResourceList) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%v: got %v, want %v", what, got, want)
+		return
+	}
+	for name, q := range want {
+		if g, ok := got[name]; !ok || g.Cmp(q) != 0 {
+			t.Errorf("%v: got %v, want %v", what, got, want)
+			return
+		}
+	}
+}
+
+func TestTightestQuota(t *testing.T) {
+	quota := func(hard, used corev1.ResourceList, scopes ...corev1.ResourceQuotaScope) corev1.ResourceQuota {
+		return corev1.ResourceQuota{
+			Spec:   corev1.ResourceQuotaSpec{Hard: hard, Scopes: scopes},
+			Status: corev1.ResourceQuotaStatus{Hard: hard, Used: used},
+		}
+	}
+	tests := []struct {
+		name   string
+		quotas []corev1.ResourceQuota
+		hard   corev1.ResourceList
+		used   corev1.ResourceList
+	}{
+		{
+			name: "no quotas",
+			hard: corev1.ResourceList{},
+			used: corev1.ResourceList{},
+		},
+		{
+			name: "one quota",
+			quotas: []corev1.ResourceQuota{
+				quota(resourceList("requests.cpu", "2", "limits.memory", "4Gi"), resourceList("requests.cpu", "500m", "limits.memory", "1Gi")),
+			},
+			hard: resourceList("requests.cpu", "2", "limits.memory", "4Gi"),
+			used: resourceList("requests.cpu", "500m", "limits.memory", "1Gi"),
+		},
+		{
+			name: "tightest of each resource",
+			quotas: []corev1.ResourceQuota{
+				quota(resourceList("requests.cpu", "2", "limits.memory", "2Gi"), resourceList("requests.cpu", "500m", "limits.memory", "1Gi")),
+				quota(resourceList("requests.cpu", "1", "limits.memory", "8Gi"), resourceList("requests.cpu", "400m", "limits.memory", "1Gi")),
+			},
+			hard: resourceList("requests.cpu", "1", "limits.memory", "2Gi"),
+			used: resourceList("requests.cpu", "400m", "limits.memory", "1Gi"),
+		},
+		{
+			name: "scoped quotas left",
+			quotas: []corev1.ResourceQuota{
+				quota(resourceList("requests.cpu", "1"), resourceList("requests.cpu", "100m"), corev1.ResourceQuotaScopeBestEffort),
+				quota(resourceList("requests.cpu", "2"), resourceList("requests.cpu", "500m")),
+			},
+			hard: resourceList("requests.cpu", "2"),
+			used: resourceList("requests.cpu", "500m"),
+		},
+		{
+			name: "not used yet",
+			quotas: []corev1.ResourceQuota{
+				quota(resourceList("requests.cpu", "2"), nil),
+			},
+			hard: resourceList("requests.cpu", "2"),
+			used: resourceList("requests.cpu", "0"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hard, used := tightestQuota(tt.quotas)
+			assertResourceList(t, "hard", hard, tt.hard)
+			assertResourceList(t, "used", used, tt.used)
+		})
+	}
+}
+
+func TestSplitQuota(t *testing.T) {
+	tests := []struct {
+		name     string
+		lst      corev1.ResourceList
+		requests corev1.ResourceList
+		limits   corev1.ResourceList
+	}{
+		{
+			name:     "empty",
+			requests: corev1.ResourceList{},
+			limits:   corev1.ResourceList{},
+		},
+		{
+			name:     "requests and limits",
+			lst:      resourceList("requests.cpu", "1", "requests.memory", "1Gi", "limits.cpu", "2", "limits.memory", "2Gi"),
+			requests: resourceList("cpu", "1", "memory", "1Gi"),
+			limits:   resourceList("cpu", "2", "memory", "2Gi"),
+		},
+		{
+			name:     "bare names are on the requests",
+			lst:      resourceList("cpu", "1", "memory", "1Gi", "limits.cpu", "2"),
+			requests: resourceList("cpu", "1", "memory", "1Gi"),
+			limits:   resourceList("cpu", "2"),
+		},
+		{
+			name:     "requests over the bare names",
+			lst:      resourceList("cpu", "4", "requests.cpu", "1"),
+			requests: resourceList("cpu", "1"),
+			limits:   corev1.ResourceList{},
+		},
+		{
+			name:     "others left",
+			lst:      resourceList("pods", "10", "requests.nvidia.com/gpu", "2"),
+			requests: corev1.ResourceList{},
+			limits:   corev1.ResourceList{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, limits := splitQuota(tt.lst)
+			assertResourceList(t, "requests", requests, tt.requests)
+			assertResourceList(t, "limits", limits, tt.limits)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package resource
+
+import (
+	"image"
+	"sort"
+
+	. "github.com/ynqa/ktop/pkg/util"
+)
+
+var (
+	namespaceTitle  = "⎈ Namespace ⎈"
+	namespaceHeader = []string{
+		"NAMESPACE", "PODS",
+		"CPU(U)", "CPU(R)", "CPU(L)", "CPU(QR)", "CPU(QL)", "%CPU(QR)", "%CPU(QL)",
+		"Memory(U)", "Memory(R)", "Memory(L)", "Memory(QR)", "Memory(QL)", "%Memory(QR)", "%Memory(QL)",
+	}
+	namespaceTableWidthFn = func(rect image.Rectangle, maxLen int) []int {
+		nameWidth := IntMax(30, IntMin(rect.Dx()-160, maxLen+indentSize))
+		return []int{nameWidth, 8, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 11, 11, 12, 12}
+	}
+	namespaceSortColumns = []sortColumn{
+		{ByName, 0}, {ByPods, 1},
+		{ByCpuUsage, 2}, {ByCpuRequests, 3}, {ByCpuLimits, 4},
+		{ByCpuRequestQuotaPercentage, 7}, {ByCpuLimitQuotaPercentage, 8},
+		{ByMemoryUsage, 9}, {ByMemoryRequests, 10}, {ByMemoryLimits, 11},
+		{ByMemoryRequestQuotaPercentage, 14}, {ByMemoryLimitQuotaPercentage, 15},
+	}
+)
+
+func AsNamespaceTableViewer(resources []*NamespaceResource, sortType SortType, reverse bool) ResourceTableViewer {
+	return &namespaceTableViewer{
+		resources: resources,
+		sortType:  sortType,
+		reverse:   reverse,
+	}
+}
+
+type namespaceTableViewer struct {
+	resources []*NamespaceResource
+	sortType  SortType
+	reverse   bool
+}
+
+func (s *namespaceTableViewer) GetTableShape(rect image.Rectangle) (string, []string, []int, [][]string) {
+	rows := make([][]string, len(s.resources))
+	var maxLen int
+	for i, v := range s.resources {
+		rows[i] = v.toRow()
+		maxLen = IntMax(maxLen, len(rows[i][0]))
+	}
+	title, header, widths :=
+		namespaceTitle,
+		markHeader(namespaceHeader, namespaceSortColumns, s.sortType, s.reverse),
+		namespaceTableWidthFn(rect, maxLen)
+
+	if len(s.resources) == 0 {
+		header = emptyHeader
+		widths = emptyWidthFn(rect)
+		rows = emptyRows
+	}
+	return title, header, widths, rows
+}
+
+func (s *namespaceTableViewer) GetHeader() []string {
+	return namespaceHeader
+}
+
+func (s *namespaceTableViewer) GetRows() [][]string {
+	rows := make([][]string, len(s.resources))
+	for i, v := range s.resources {
+		rows[i] = v.toRow()
+	}
+	return rows
+}
+
+func (s *namespaceTableViewer) GetLevels(t Thresholds) [][]Level {
+	levels := make([][]Level, len(s.resources))
+	for i, v := range s.resources {
+		levels[i] = v.levels(t)
+	}
+	return levels
+}
+
+func (s *namespaceTableViewer) SortRows() {
+	sort.SliceStable(s.resources, func(i, j int) bool {
+		x, y := s.resources[i], s.resources[j]
+		return lessBy(s.sortType, s.reverse,
+			x.sortValue(s.sortType), y.sortValue(s.sortType),
+			x.names(), y.names())
+	})
+}
@@ -1,6 +1,13 @@
 package resource
 
 import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/metrics/pkg/apis/metrics"
 
@@ -12,14 +19,37 @@ type NodeResource struct {
 	capacity    corev1.ResourceList
 	allocatable corev1.ResourceList
 	usage       corev1.ResourceList
+	// committed by the pods on the node
+	requests corev1.ResourceList
+	limits   corev1.ResourceList
+	node     corev1.Node
+	// pods scheduled on the node
+	pods []*Pod
+	// false if the pods are unknown, and so are the requests and the limits
+	podsKnown bool
 }
 
-func NewNodeResource(n corev1.Node, nm metrics.NodeMetrics) *NodeResource {
+// NewNodeResource sums up the requests and the limits of the pods on the node, if podsKnown.
+func NewNodeResource(n corev1.Node, nm metrics.NodeMetrics, pods []*Pod, podsKnown bool) *NodeResource {
+	var requests, limits corev1.ResourceList
+	if podsKnown {
+		requests, limits = corev1.ResourceList{}, corev1.ResourceList{}
+	}
+	for _, p := range pods {
+		podRequests, podLimits := p.requestsAndLimits()
+		addResourceList(requests, podRequests)
+		addResourceList(limits, podLimits)
+	}
 	return &NodeResource{
 		nodeName:    nm.Name,
 		capacity:    n.Status.Capacity,
 		allocatable: n.Status.Allocatable,
 		usage:       nm.Usage,
+		requests:    requests,
+		limits:      limits,
+		node:        n,
+		pods:        pods,
+		podsKnown:   podsKnown,
 	}
 }
 
@@ -37,15 +67,246 @@ func (r *NodeResource) GetMemoryUsagePercentage() (float64, string) {
 		GetResourcePercentageString(*r.usage.Memory(), *r.allocatable.Memory())
 }
 
-// header: "NODE", "CPU(C)", "CPU(A)", "CPU(U)", "%CPU", "Memory(C)", "Memory(A)", "Memory(U)", "%Memory",
+// GetCpuRequestPercentage returns the cpu requests of the pods in percent of the allocatable.
+func (r *NodeResource) GetCpuRequestPercentage() (float64, string) {
+	return r.committedPercentage(r.requests, corev1.ResourceCPU)
+}
+
+func (r *NodeResource) GetCpuLimitPercentage() (float64, string) {
+	return r.committedPercentage(r.limits, corev1.ResourceCPU)
+}
+
+func (r *NodeResource) GetMemoryRequestPercentage() (float64, string) {
+	return r.committedPercentage(r.requests, corev1.ResourceMemory)
+}
+
+func (r *NodeResource) GetMemoryLimitPercentage() (float64, string) {
+	return r.committedPercentage(r.limits, corev1.ResourceMemory)
+}
+
+// HasPods reports whether the pods on the node are known, and so are the requests and the limits.
+func (r *NodeResource) HasPods() bool {
+	return r.podsKnown
+}
+
+// committedPercentage returns the requests or the limits in percent of the allocatable, or "-".
+func (r *NodeResource) committedPercentage(lst corev1.ResourceList, name corev1.ResourceName) (float64, string) {
+	if !r.podsKnown {
+		return 0, "-"
+	}
+	return percentage(lst, r.allocatable, name), percentageString(lst, r.allocatable, name)
+}
+
+func (r *NodeResource) committedPercentageString(lst corev1.ResourceList, name corev1.ResourceName) string {
+	_, str := r.committedPercentage(lst, name)
+	return str
+}
+
+// Key identifies the node.
+func (r *NodeResource) Key() string {
+	return "node/" + r.nodeName
+}
+
+func (r *NodeResource) names() []string {
+	return []string{r.nodeName}
+}
+
+func (r *NodeResource) sortValue(sortType SortType) float64 {
+	switch sortType {
+	case ByCpuAllocatable:
+		return GetResourceValue(r.allocatable, corev1.ResourceCPU)
+	case ByCpuUsage:
+		return GetResourceValue(r.usage, corev1.ResourceCPU)
+	case ByCpuRequests:
+		return GetResourceValue(r.requests, corev1.ResourceCPU)
+	case ByCpuLimits:
+		return GetResourceValue(r.limits, corev1.ResourceCPU)
+	case ByCpuPercentage:
+		v, _ := r.GetCpuUsagePercentage()
+		return finiteOrZero(v)
+	case ByCpuRequestPercentage:
+		return percentage(r.requests, r.allocatable, corev1.ResourceCPU)
+	case ByCpuLimitPercentage:
+		return percentage(r.limits, r.allocatable, corev1.ResourceCPU)
+	case ByMemoryAllocatable:
+		return GetResourceValue(r.allocatable, corev1.ResourceMemory)
+	case ByMemoryUsage:
+		return GetResourceValue(r.usage, corev1.ResourceMemory)
+	case ByMemoryRequests:
+		return GetResourceValue(r.requests, corev1.ResourceMemory)
+	case ByMemoryLimits:
+		return GetResourceValue(r.limits, corev1.ResourceMemory)
+	case ByMemoryPercentage:
+		v, _ := r.GetMemoryUsagePercentage()
+		return finiteOrZero(v)
+	case ByMemoryRequestPercentage:
+		return percentage(r.requests, r.allocatable, corev1.ResourceMemory)
+	case ByMemoryLimitPercentage:
+		return percentage(r.limits, r.allocatable, corev1.ResourceMemory)
+	default:
+		return 0
+	}
+}
+
+// levels colors the percentages of the usage.
+func (r *NodeResource) levels(t Thresholds) []Level {
+	return t.usageLevels(len(nodeHeader), r.usage, r.allocatable, 5, 12)
+}
+
+// Level returns how close the usage is to the allocatable.
+func (r *NodeResource) Level(t Thresholds) Level {
+	return maxLevel(r.levels(t))
+}
+
+// header: "NODE",
+// "CPU(A)", "CPU(U)", "CPU(R)", "CPU(L)", "%CPU", "%CPU(R)", "%CPU(L)",
+// "Memory(A)", "Memory(U)", "Memory(R)", "Memory(L)", "%Memory", "%Memory(R)", "%Memory(L)"
 func (r *NodeResource) toRow() []string {
 	return []string{
 		r.nodeName,
 		GetResourceValueString(r.allocatable, corev1.ResourceCPU),
 		GetResourceValueString(r.usage, corev1.ResourceCPU),
+		GetResourceValueString(r.requests, corev1.ResourceCPU),
+		GetResourceValueString(r.limits, corev1.ResourceCPU),
 		GetResourcePercentageString(*r.usage.Cpu(), *r.allocatable.Cpu()),
+		r.committedPercentageString(r.requests, corev1.ResourceCPU),
+		r.committedPercentageString(r.limits, corev1.ResourceCPU),
 		GetResourceValueString(r.allocatable, corev1.ResourceMemory),
 		GetResourceValueString(r.usage, corev1.ResourceMemory),
+		GetResourceValueString(r.requests, corev1.ResourceMemory),
+		GetResourceValueString(r.limits, corev1.ResourceMemory),
 		GetResourcePercentageString(*r.usage.Memory(), *r.allocatable.Memory()),
+		r.committedPercentageString(r.requests, corev1.ResourceMemory),
+		r.committedPercentageString(r.limits, corev1.ResourceMemory),
+	}
+}
+
+// extraRow shows the allocatable, the requests and the limits of the resources other than cpu and memory.
+func (r *NodeResource) extraRow(names []corev1.ResourceName) []string {
+	row := make([]string, 0, len(names)*3)
+	for _, name := range names {
+		row = append(row,
+			GetResourceValueString(r.allocatable, name),
+			GetResourceValueString(r.requests, name),
+			GetResourceValueString(r.limits, name),
+		)
+	}
+	return row
+}
+
+// extraLevels colors the requests by the allocatable, which pods fail to be scheduled over.
+func (r *NodeResource) extraLevels(t Thresholds, names []corev1.ResourceName) []Level {
+	levels := make([]Level, len(names)*3)
+	for i, name := range names {
+		levels[i*3+1] = t.levelOf(percentage(r.requests, r.allocatable, name))
+	}
+	return levels
+}
+
+// Describe shows the node in detail, with the pods scheduled on it
+// and their shares of the allocatable.
+func (r *NodeResource) Describe(now time.Time) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Name: %v\n", r.nodeName)
+	fmt.Fprintf(&buf, "Kubelet Version: %v\n", orNone(r.node.Status.NodeInfo.KubeletVersion))
+	fmt.Fprintf(&buf, "Age: %v\n", age(r.node.CreationTimestamp.Time, now))
+
+	labels := make([]string, 0, len(r.node.Labels))
+	for k, v := range r.node.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	fmt.Fprintf(&buf, "Labels: %v\n", orNone(strings.Join(labels, ", ")))
+
+	taints := make([]string, len(r.node.Spec.Taints))
+	for i, taint := range r.node.Spec.Taints {
+		taints[i] = taint.ToString()
+	}
+	fmt.Fprintf(&buf, "Taints: %v\n", orNone(strings.Join(taints, ", ")))
+	buf.WriteString("\n")
+
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONDITION\tSTATUS\tREASON\tAGE")
+	for _, c := range r.node.Status.Conditions {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n",
+			c.Type, c.Status, orNone(c.Reason), age(c.LastTransitionTime.Time, now))
+	}
+	fmt.Fprintln(w, "\t\t\t\t")
+
+	fmt.Fprintln(w, "RESOURCE\tCAPACITY\tALLOCATABLE\tREQUESTS\tLIMITS")
+	for _, name := range resourceNames(r.capacity, r.allocatable) {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n",
+			name,
+			quantityString(r.capacity, name),
+			quantityString(r.allocatable, name),
+			quantityString(r.requests, name),
+			quantityString(r.limits, name))
+	}
+	w.Flush()
+	buf.WriteString("\n")
+
+	if !r.podsKnown {
+		buf.WriteString("Pods are unknown, which are not listed yet or not allowed to list cluster-wide\n")
+		return buf.String()
+	}
+	pods := make([]*Pod, len(r.pods))
+	copy(pods, r.pods)
+	sort.Slice(pods, func(i, j int) bool {
+		x, y := pods[i].pod, pods[j].pod
+		return lessNames([]string{x.Namespace, x.Name}, []string{y.Namespace, y.Name})
+	})
+	w = tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tPOD\tCPU(U)\t%CPU\tMemory(U)\t%Memory")
+	unknown := 0
+	for _, p := range pods {
+		// the usage of the pods out of the namespaces to watch is not fetched
+		if !p.hasMetrics() {
+			unknown++
+			fmt.Fprintln(w, strings.Join([]string{p.pod.Namespace, p.pod.Name, "n/a", "n/a", "n/a", "n/a"}, "\t"))
+			continue
+		}
+		usage := p.usage()
+		fmt.Fprintln(w, strings.Join([]string{
+			p.pod.Namespace,
+			p.pod.Name,
+			GetResourceValueString(usage, corev1.ResourceCPU),
+			GetResourcePercentageString(*usage.Cpu(), *r.allocatable.Cpu()),
+			GetResourceValueString(usage, corev1.ResourceMemory),
+			GetResourcePercentageString(*usage.Memory(), *r.allocatable.Memory()),
+		}, "\t"))
+	}
+	w.Flush()
+	if unknown > 0 {
+		fmt.Fprintf(&buf, "\nUsage of %v pods is n/a, which are out of the namespaces to watch or have no metrics yet\n", unknown)
+	}
+	return buf.String()
+}
+
+// resourceNames returns the names of resources in any of the lists in order.
+func resourceNames(lists ...corev1.ResourceList) []corev1.ResourceName {
+	seen := make(map[corev1.ResourceName]bool)
+	names := make([]corev1.ResourceName, 0)
+	for _, lst := range lists {
+		for name := range lst {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// quantityString formats the resources as the tables do, and others, e.g. pods, as they are.
+func quantityString(lst corev1.ResourceList, name corev1.ResourceName) string {
+	if name == corev1.ResourceCPU || name == corev1.ResourceMemory || IsExtraResourceName(name) {
+		return GetResourceValueString(lst, name)
+	}
+	if q, ok := lst[name]; ok {
+		return q.String()
 	}
+	return "-"
 }
@@ -4,6 +4,8 @@ import (
 	"image"
 	"sort"
 
+	corev1 "k8s.io/api/core/v1"
+
 	. "github.com/ynqa/ktop/pkg/util"
 )
 
@@ -11,37 +13,64 @@ var (
 	nodeTitle  = "⎈ Node ⎈"
 	nodeHeader = []string{
 		"NODE",
-		"CPU(A)", "CPU(U)", "%CPU",
-		"Memory(A)", "Memory(U)", "%Memory",
+		"CPU(A)", "CPU(U)", "CPU(R)", "CPU(L)", "%CPU", "%CPU(R)", "%CPU(L)",
+		"Memory(A)", "Memory(U)", "Memory(R)", "Memory(L)", "%Memory", "%Memory(R)", "%Memory(L)",
 	}
+	// the percentages of the requests and limits are hidden first on narrow terminals, and then the requests and limits
 	nodeWidthFn = func(rect image.Rectangle, maxLen int) []int {
-		nameWidth := IntMax(50, IntMin(rect.Dx()-20, maxLen+indentSize))
-		return []int{nameWidth, 10, 10, 10, 10, 10, 10}
+		widths := fitWidthsFn(rect.Dx()-30,
+			[]int{10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 11, 11},
+			[]int{5, 6, 12, 13}, []int{2, 3, 9, 10},
+		)
+		nameWidth := IntMax(30, IntMin(rect.Dx()-IntSum(widths...), maxLen+indentSize))
+		return append([]int{nameWidth}, widths...)
+	}
+	// columns of each resource other than cpu and memory
+	nodeExtraSuffixes = []string{"(A)", "(R)", "(L)"}
+	nodeSortColumns   = []sortColumn{
+		{ByName, 0},
+		{ByCpuAllocatable, 1}, {ByCpuUsage, 2}, {ByCpuRequests, 3}, {ByCpuLimits, 4},
+		{ByCpuPercentage, 5}, {ByCpuRequestPercentage, 6}, {ByCpuLimitPercentage, 7},
+		{ByMemoryAllocatable, 8}, {ByMemoryUsage, 9}, {ByMemoryRequests, 10}, {ByMemoryLimits, 11},
+		{ByMemoryPercentage, 12}, {ByMemoryRequestPercentage, 13}, {ByMemoryLimitPercentage, 14},
 	}
 )
 
-func AsNodeTableViewer(resources []*NodeResource, sortType SortType) ResourceTableViewer {
-	switch sortType {
-	case ByName:
-		return sortByNameForNode(resources)
-	default:
-		return sortByNameForNode(resources)
+func AsNodeTableViewer(resources []*NodeResource, sortType SortType, reverse bool) ResourceTableViewer {
+	lists := make([]corev1.ResourceList, 0, len(resources)*3)
+	for _, r := range resources {
+		lists = append(lists, r.allocatable, r.requests, r.limits)
+	}
+	return &nodeTableViewer{
+		resources: resources,
+		extra:     extraResourceNames(lists...),
+		sortType:  sortType,
+		reverse:   reverse,
 	}
 }
 
-type sortByNameForNode []*NodeResource
+type nodeTableViewer struct {
+	resources []*NodeResource
+	// resources other than cpu and memory, which are put on the right
+	extra    []corev1.ResourceName
+	sortType SortType
+	reverse  bool
+}
 
-func (s sortByNameForNode) GetTableShape(rect image.Rectangle) (string, []string, []int, [][]string) {
-	rows := make([][]string, len(s))
+func (s *nodeTableViewer) GetTableShape(rect image.Rectangle) (string, []string, []int, [][]string) {
+	rows := make([][]string, len(s.resources))
 	var maxLen int
-	for i, v := range s {
-		rows[i] = v.toRow()
+	for i, v := range s.resources {
+		rows[i] = append(v.toRow(), v.extraRow(s.extra)...)
 		maxLen = IntMax(maxLen, len(rows[i][0]))
 	}
+	extra := extraHeader(s.extra, nodeExtraSuffixes...)
 	title, header, widths :=
-		nodeTitle, nodeHeader, nodeWidthFn(rect, maxLen)
+		nodeTitle,
+		withExtra(markHeader(nodeHeader, nodeSortColumns, s.sortType, s.reverse), extra),
+		append(nodeWidthFn(rect, maxLen), extraWidths(extra)...)
 
-	if len(s) == 0 {
+	if len(s.resources) == 0 {
 		header = emptyHeader
 		widths = emptyWidthFn(rect)
 		rows = emptyRows
@@ -49,19 +78,31 @@ func (s sortByNameForNode) GetTableShape(rect image.Rectangle) (string, []string
 	return title, header, widths, rows
 }
 
-func (s sortByNameForNode) GetRows() [][]string {
-	if len(s) == 0 {
-		return emptyRows
-	}
-	rows := make([][]string, len(s))
-	for i, v := range s {
-		rows[i] = v.toRow()
+func (s *nodeTableViewer) GetHeader() []string {
+	return withExtra(nodeHeader, extraHeader(s.extra, nodeExtraSuffixes...))
+}
+
+func (s *nodeTableViewer) GetRows() [][]string {
+	rows := make([][]string, len(s.resources))
+	for i, v := range s.resources {
+		rows[i] = append(v.toRow(), v.extraRow(s.extra)...)
 	}
 	return rows
 }
 
-func (s sortByNameForNode) SortRows() {
-	sort.Slice(s, func(i, j int) bool {
-		return s[i].nodeName < s[j].nodeName
+func (s *nodeTableViewer) GetLevels(t Thresholds) [][]Level {
+	levels := make([][]Level, len(s.resources))
+	for i, v := range s.resources {
+		levels[i] = append(v.levels(t), v.extraLevels(t, s.extra)...)
+	}
+	return levels
+}
+
+func (s *nodeTableViewer) SortRows() {
+	sort.SliceStable(s.resources, func(i, j int) bool {
+		x, y := s.resources[i], s.resources[j]
+		return lessBy(s.sortType, s.reverse,
+			x.sortValue(s.sortType), y.sortValue(s.sortType),
+			x.names(), y.names())
 	})
 }
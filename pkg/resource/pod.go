@@ -0,0 +1,200 @@
+package resource
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/metrics/pkg/apis/metrics"
+
+	. "github.com/ynqa/ktop/pkg/util"
+)
+
+// Pod keeps the pod and the metrics of all its containers,
+// which the rows of pods and containers are made from, to describe it.
+type Pod struct {
+	pod        corev1.Pod
+	podMetrics metrics.PodMetrics
+}
+
+func NewPod(p corev1.Pod, pm metrics.PodMetrics) *Pod {
+	return &Pod{
+		pod:        p,
+		podMetrics: pm,
+	}
+}
+
+// Describe shows the pod in detail, with the requests, limits and usage
+// of every container side by side.
+func (p *Pod) Describe(now time.Time) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Name: %v\n", p.pod.Name)
+	fmt.Fprintf(&buf, "Namespace: %v\n", p.pod.Namespace)
+	fmt.Fprintf(&buf, "Node: %v\n", orNone(p.pod.Spec.NodeName))
+	fmt.Fprintf(&buf, "Phase: %v\n", orNone(string(p.pod.Status.Phase)))
+	fmt.Fprintf(&buf, "QoS Class: %v\n", orNone(string(p.pod.Status.QOSClass)))
+	fmt.Fprintf(&buf, "Owner: %v\n", p.owner())
+	fmt.Fprintf(&buf, "Age: %v\n", age(p.pod.CreationTimestamp.Time, now))
+	fmt.Fprintf(&buf, "Restarts: %v\n", p.restarts())
+	fmt.Fprintf(&buf, "Last Termination: %v\n", p.lastTermination(now))
+	buf.WriteString("\n")
+
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join([]string{
+		"CONTAINER",
+		"CPU(U)", "CPU(R)", "CPU(L)",
+		"Memory(U)", "Memory(R)", "Memory(L)",
+		"RESTARTS", "LAST TERMINATION",
+	}, "\t"))
+	for _, c := range p.pod.Spec.Containers {
+		var usage corev1.ResourceList
+		for _, cm := range p.podMetrics.Containers {
+			if cm.Name == c.Name {
+				usage = cm.Usage
+			}
+		}
+		restarts, reason := "-", "-"
+		if status := p.containerStatus(c.Name); status != nil {
+			restarts = fmt.Sprint(status.RestartCount)
+			if t := lastTerminated(status); t != nil {
+				reason = t.Reason
+			}
+		}
+		fmt.Fprintln(w, strings.Join([]string{
+			c.Name,
+			GetResourceValueString(usage, corev1.ResourceCPU),
+			GetResourceValueString(c.Resources.Requests, corev1.ResourceCPU),
+			GetResourceValueString(c.Resources.Limits, corev1.ResourceCPU),
+			GetResourceValueString(usage, corev1.ResourceMemory),
+			GetResourceValueString(c.Resources.Requests, corev1.ResourceMemory),
+			GetResourceValueString(c.Resources.Limits, corev1.ResourceMemory),
+			restarts,
+			orNone(reason),
+		}, "\t"))
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// hasMetrics reports whether the metrics of the pod are fetched,
+// which they are not for the pods out of the namespaces to watch.
+func (p *Pod) hasMetrics() bool {
+	return p.podMetrics.Name != ""
+}
+
+// usage sums the usage of all the containers.
+func (p *Pod) usage() corev1.ResourceList {
+	usage := corev1.ResourceList{}
+	for _, cm := range p.podMetrics.Containers {
+		addResourceList(usage, cm.Usage)
+	}
+	return usage
+}
+
+// requestsAndLimits returns the resources which the pod holds on the node, as kubectl describe node does.
+func (p *Pod) requestsAndLimits() (corev1.ResourceList, corev1.ResourceList) {
+	requests, limits := corev1.ResourceList{}, corev1.ResourceList{}
+	for _, c := range p.pod.Spec.Containers {
+		addResourceList(requests, c.Resources.Requests)
+		addResourceList(limits, c.Resources.Limits)
+	}
+	for _, c := range p.pod.Spec.InitContainers {
+		maxResourceList(requests, c.Resources.Requests)
+		maxResourceList(limits, c.Resources.Limits)
+	}
+	return requests, limits
+}
+
+func addResourceList(lst, other corev1.ResourceList) {
+	for name, q := range other {
+		sum := lst[name]
+		sum.Add(q)
+		lst[name] = sum
+	}
+}
+
+func maxResourceList(lst, other corev1.ResourceList) {
+	for name, q := range other {
+		if current, ok := lst[name]; !ok || q.Cmp(current) > 0 {
+			lst[name] = q.DeepCopy()
+		}
+	}
+}
+
+func (p *Pod) owner() string {
+	if ref := controllerOf(p.pod.OwnerReferences); ref != nil {
+		return ref.Kind + "/" + ref.Name
+	}
+	if len(p.pod.OwnerReferences) > 0 {
+		ref := p.pod.OwnerReferences[0]
+		return ref.Kind + "/" + ref.Name
+	}
+	return "-"
+}
+
+func (p *Pod) restarts() int32 {
+	var restarts int32
+	for _, status := range p.pod.Status.ContainerStatuses {
+		restarts += status.RestartCount
+	}
+	return restarts
+}
+
+// lastTermination describes the latest termination among the containers.
+func (p *Pod) lastTermination(now time.Time) string {
+	var (
+		latest    *corev1.ContainerStateTerminated
+		container string
+	)
+	for i := range p.pod.Status.ContainerStatuses {
+		status := &p.pod.Status.ContainerStatuses[i]
+		t := lastTerminated(status)
+		if t == nil {
+			continue
+		}
+		if latest == nil || t.FinishedAt.After(latest.FinishedAt.Time) {
+			latest, container = t, status.Name
+		}
+	}
+	if latest == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%v (container: %v, exit code: %v, %v ago)",
+		orNone(latest.Reason), container, latest.ExitCode, age(latest.FinishedAt.Time, now))
+}
+
+func (p *Pod) containerStatus(name string) *corev1.ContainerStatus {
+	for i, status := range p.pod.Status.ContainerStatuses {
+		if status.Name == name {
+			return &p.pod.Status.ContainerStatuses[i]
+		}
+	}
+	return nil
+}
+
+// lastTerminated returns the termination of the container running now if any,
+// otherwise the one of the previous run.
+func lastTerminated(status *corev1.ContainerStatus) *corev1.ContainerStateTerminated {
+	if status.State.Terminated != nil {
+		return status.State.Terminated
+	}
+	return status.LastTerminationState.Terminated
+}
+
+func age(t, now time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return duration.HumanDuration(now.Sub(t))
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
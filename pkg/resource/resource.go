@@ -8,25 +8,33 @@ import (
 )
 
 type Resource struct {
+	namespace     string
 	nodeName      string
 	podName       string
 	containerName string
 	usage         corev1.ResourceList
 	limits        corev1.ResourceList
 	requests      corev1.ResourceList
+	pod           *Pod
 }
 
-func NewResource(p corev1.Pod, c corev1.Container, cm metrics.ContainerMetrics) *Resource {
+func NewResource(p *Pod, c corev1.Container, cm metrics.ContainerMetrics) *Resource {
 	return &Resource{
-		nodeName:      p.Spec.NodeName,
-		podName:       p.Name,
+		namespace:     p.pod.Namespace,
+		nodeName:      p.pod.Spec.NodeName,
+		podName:       p.pod.Name,
 		containerName: c.Name,
 		usage:         cm.Usage,
 		limits:        c.Resources.Limits,
 		requests:      c.Resources.Requests,
+		pod:           p,
 	}
 }
 
+func (r *Resource) GetNamespace() string {
+	return r.namespace
+}
+
 func (r *Resource) GetNodeName() string {
 	return r.nodeName
 }
@@ -35,12 +43,22 @@ func (r *Resource) GetContainerName() string {
 	return r.containerName
 }
 
+func (r *Resource) GetPod() *Pod {
+	return r.pod
+}
+
 func (r *Resource) GetCpuLimits() (float64, string, bool) {
 	_, ok := r.limits[corev1.ResourceCPU]
 	str := GetResourceValueString(r.limits, corev1.ResourceCPU)
 	return GetResourceValue(r.limits, corev1.ResourceCPU), str, ok
 }
 
+func (r *Resource) GetCpuRequests() (float64, string, bool) {
+	_, ok := r.requests[corev1.ResourceCPU]
+	str := GetResourceValueString(r.requests, corev1.ResourceCPU)
+	return GetResourceValue(r.requests, corev1.ResourceCPU), str, ok
+}
+
 func (r *Resource) GetCpuUsage() (float64, string) {
 	return GetResourceValue(r.usage, corev1.ResourceCPU),
 		GetResourceValueString(r.usage, corev1.ResourceCPU)
@@ -52,14 +70,59 @@ func (r *Resource) GetMemoryLimits() (float64, string, bool) {
 	return GetResourceValue(r.limits, corev1.ResourceMemory), str, ok
 }
 
+func (r *Resource) GetMemoryRequests() (float64, string, bool) {
+	_, ok := r.requests[corev1.ResourceMemory]
+	str := GetResourceValueString(r.requests, corev1.ResourceMemory)
+	return GetResourceValue(r.requests, corev1.ResourceMemory), str, ok
+}
+
 func (r *Resource) GetMemoryUsage() (float64, string) {
 	return GetResourceValue(r.usage, corev1.ResourceMemory),
 		GetResourceValueString(r.usage, corev1.ResourceMemory)
 }
 
-// header: "POD", "CONTAINER", "CPU(U)", "CPU(L)", "CPU(R)", "Mem(U)", "Mem(L)", "Mem(R)"
+// Key identifies the container across namespaces.
+func (r *Resource) Key() string {
+	return "container/" + r.namespace + "/" + r.podName + "/" + r.containerName
+}
+
+func (r *Resource) names() []string {
+	return []string{r.namespace, r.podName, r.containerName}
+}
+
+func (r *Resource) sortValue(sortType SortType) float64 {
+	switch sortType {
+	case ByCpuUsage:
+		return GetResourceValue(r.usage, corev1.ResourceCPU)
+	case ByCpuLimits:
+		return GetResourceValue(r.limits, corev1.ResourceCPU)
+	case ByCpuRequests:
+		return GetResourceValue(r.requests, corev1.ResourceCPU)
+	case ByMemoryUsage:
+		return GetResourceValue(r.usage, corev1.ResourceMemory)
+	case ByMemoryLimits:
+		return GetResourceValue(r.limits, corev1.ResourceMemory)
+	case ByMemoryRequests:
+		return GetResourceValue(r.requests, corev1.ResourceMemory)
+	default:
+		return 0
+	}
+}
+
+// levels colors the usage by the limits.
+func (r *Resource) levels(t Thresholds) []Level {
+	return t.usageLevels(len(allHeader), r.usage, r.limits, 3, 6)
+}
+
+// Level returns how close the usage is to the limits.
+func (r *Resource) Level(t Thresholds) Level {
+	return maxLevel(r.levels(t))
+}
+
+// header: "NAMESPACE", "POD", "CONTAINER", "CPU(U)", "CPU(L)", "CPU(R)", "Mem(U)", "Mem(L)", "Mem(R)"
 func (r *Resource) toRow() []string {
 	return []string{
+		r.namespace,
 		r.podName,
 		r.containerName,
 		GetResourceValueString(r.usage, corev1.ResourceCPU),
@@ -70,3 +133,15 @@ func (r *Resource) toRow() []string {
 		GetResourceValueString(r.requests, corev1.ResourceMemory),
 	}
 }
+
+// extraRow shows the limits and the requests of the resources other than cpu and memory.
+func (r *Resource) extraRow(names []corev1.ResourceName) []string {
+	row := make([]string, 0, len(names)*2)
+	for _, name := range names {
+		row = append(row,
+			GetResourceValueString(r.limits, name),
+			GetResourceValueString(r.requests, name),
+		)
+	}
+	return row
+}
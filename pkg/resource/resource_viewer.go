@@ -3,7 +3,12 @@ package resource
 import (
 	"container/ring"
 	"image"
+	"math"
 	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 
 	. "github.com/ynqa/ktop/pkg/util"
 )
@@ -12,29 +17,93 @@ type SortType int
 
 const (
 	ByName SortType = iota
+	ByReplicas
+	ByPods
+	ByCpuUsage
+	ByCpuAverage
+	ByCpuMax
+	ByCpuLimits
+	ByCpuRequests
+	ByCpuAllocatable
+	ByCpuPercentage
+	ByCpuRequestPercentage
+	ByCpuLimitPercentage
+	ByCpuRequestQuotaPercentage
+	ByCpuLimitQuotaPercentage
+	ByMemoryUsage
+	ByMemoryAverage
+	ByMemoryMax
+	ByMemoryLimits
+	ByMemoryRequests
+	ByMemoryAllocatable
+	ByMemoryPercentage
+	ByMemoryRequestPercentage
+	ByMemoryLimitPercentage
+	ByMemoryRequestQuotaPercentage
+	ByMemoryLimitQuotaPercentage
 )
 
 type ResourceTableViewer interface {
 	GetTableShape(rect image.Rectangle) (string, []string, []int, [][]string)
+	// GetHeader and GetRows return the plain table without decorations for the terminal
+	GetHeader() []string
+	GetRows() [][]string
+	// GetLevels returns the levels of the cells in the rows
+	GetLevels(t Thresholds) [][]Level
 	SortRows()
 }
 
+// sortColumn binds a sort type to the index of the column sorted by it.
+type sortColumn struct {
+	sortType SortType
+	index    int
+}
+
 var (
 	SummarizedType = "Summarized"
 	AllType        = "All"
 	NodeType       = "Node"
+	WorkloadType   = "Workload"
+	NamespaceType  = "Namespace"
+	GroupType      = "Group"
+	tableTypes     = []string{SummarizedType, AllType, NodeType, WorkloadType, NamespaceType, GroupType}
 
 	allTitle  = "⎈ Pod/Container ⎈"
 	allHeader = []string{
-		"POD", "CONTAINER",
+		"NAMESPACE", "POD", "CONTAINER",
 		"CPU(U)", "CPU(L)", "CPU(R)",
 		"Memory(U)", "Memory(L)", "Memory(R)",
 	}
 	indentSize = 4
-	allWidthFn = func(rect image.Rectangle, maxLen0, maxLen1 int) []int {
-		podWidth := IntMax(40, IntMin(rect.Dx()-60, maxLen0+indentSize))
-		containerWidth := IntMax(30, IntMin(rect.Dx()-60, maxLen1+indentSize))
-		return []int{podWidth, containerWidth, 10, 10, 10, 10, 10, 10}
+	allWidthFn = func(rect image.Rectangle, maxLen0, maxLen1, maxLen2 int) []int {
+		namespaceWidth := namespaceWidthFn(rect, maxLen0)
+		podWidth := IntMax(40, IntMin(rect.Dx()-namespaceWidth-60, maxLen1+indentSize))
+		containerWidth := IntMax(30, IntMin(rect.Dx()-namespaceWidth-60, maxLen2+indentSize))
+		return []int{namespaceWidth, podWidth, containerWidth, 10, 10, 10, 10, 10, 10}
+	}
+	// columns of each resource other than cpu and memory
+	allExtraSuffixes = []string{"(L)", "(R)"}
+	allSortColumns   = []sortColumn{
+		{ByName, 0},
+		{ByCpuUsage, 3}, {ByCpuLimits, 4}, {ByCpuRequests, 5},
+		{ByMemoryUsage, 6}, {ByMemoryLimits, 7}, {ByMemoryRequests, 8},
+	}
+	namespaceWidthFn = func(rect image.Rectangle, maxLen int) int {
+		return IntMax(15, IntMin(rect.Dx()/5, maxLen+indentSize))
+	}
+	// fitWidthsFn hides the columns a group at a time while the widths are over the width
+	fitWidthsFn = func(width int, widths []int, groups ...[]int) []int {
+		sum := IntSum(widths...)
+		for _, group := range groups {
+			if sum <= width {
+				break
+			}
+			for _, i := range group {
+				sum -= widths[i]
+				widths[i] = 0
+			}
+		}
+		return widths
 	}
 
 	emptyHeader = []string{
@@ -47,51 +116,216 @@ var (
 )
 
 func TableTypeCircle() *ring.Ring {
-	types := []string{SummarizedType, AllType, NodeType}
-	circle := ring.New(len(types))
-	for _, typ := range types {
+	circle := ring.New(len(tableTypes))
+	for _, typ := range tableTypes {
 		circle.Value = typ
 		circle = circle.Next()
 	}
 	return circle
 }
 
-func ResetTableShapeFrom(typ string, rect image.Rectangle) (string, []string, []int) {
+func ResetTableShapeFrom(typ string, rect image.Rectangle, sortType SortType, reverse bool) (string, []string, []int) {
 	switch typ {
 	case SummarizedType:
-		return summarizedTitle, summarizedHeader, summarizedWidthFn(rect, 0)
+		return summarizedTitle,
+			markHeader(summarizedHeader, summarizedSortColumns, sortType, reverse),
+			summarizedWidthFn(rect, 0, 0)
+	case AllType:
+		return allTitle,
+			markHeader(allHeader, allSortColumns, sortType, reverse),
+			allWidthFn(rect, 0, 0, 0)
+	case NodeType:
+		return nodeTitle,
+			markHeader(nodeHeader, nodeSortColumns, sortType, reverse),
+			nodeWidthFn(rect, 0)
+	case WorkloadType:
+		return workloadTitle,
+			markHeader(workloadHeader, workloadSortColumns, sortType, reverse),
+			workloadWidthFn(rect, 0, 0)
+	case NamespaceType:
+		return namespaceTitle,
+			markHeader(namespaceHeader, namespaceSortColumns, sortType, reverse),
+			namespaceTableWidthFn(rect, 0)
+	case GroupType:
+		return groupTitle,
+			markHeader(groupHeader, groupSortColumns, sortType, reverse),
+			groupWidthFn(rect, 0)
+	default:
+		return summarizedTitle,
+			markHeader(summarizedHeader, summarizedSortColumns, sortType, reverse),
+			summarizedWidthFn(rect, 0, 0)
+	}
+}
+
+// ParseTableType finds the table type by its name, ignoring case.
+func ParseTableType(name string) (string, error) {
+	for _, typ := range tableTypes {
+		if strings.EqualFold(typ, name) {
+			return typ, nil
+		}
+	}
+	return "", errors.Errorf("Unknown table type: %v", name)
+}
+
+// ParseSortType finds the sort type by the name of the column in the table, ignoring case,
+// e.g. CPU(U) or %Memory.
+func ParseSortType(typ, column string) (SortType, error) {
+	header := headerFor(typ)
+	for _, c := range sortColumnsFor(typ) {
+		if strings.EqualFold(header[c.index], column) {
+			return c.sortType, nil
+		}
+	}
+	return ByName, errors.Errorf("Unknown sort column for %v: %v", typ, column)
+}
+
+func headerFor(typ string) []string {
+	switch typ {
 	case AllType:
-		return allTitle, allHeader, allWidthFn(rect, 0, 0)
+		return allHeader
 	case NodeType:
-		return nodeTitle, nodeHeader, nodeWidthFn(rect, 0)
+		return nodeHeader
+	case WorkloadType:
+		return workloadHeader
+	case NamespaceType:
+		return namespaceHeader
+	case GroupType:
+		return groupHeader
 	default:
-		return summarizedTitle, summarizedHeader, summarizedWidthFn(rect, 0)
+		return summarizedHeader
 	}
 }
 
-func AsAllTableViewer(resources []*Resource, sortType SortType) ResourceTableViewer {
-	switch sortType {
-	case ByName:
-		return sortByName(resources)
+func sortColumnsFor(typ string) []sortColumn {
+	switch typ {
+	case SummarizedType:
+		return summarizedSortColumns
+	case AllType:
+		return allSortColumns
+	case NodeType:
+		return nodeSortColumns
+	case WorkloadType:
+		return workloadSortColumns
+	case NamespaceType:
+		return namespaceSortColumns
+	case GroupType:
+		return groupSortColumns
 	default:
-		return sortByName(resources)
+		return summarizedSortColumns
+	}
+}
+
+// NextSortType returns the sort type for the column next to the one
+// sorted by the current sort type, wrapping around to the first column.
+func NextSortType(typ string, current SortType) SortType {
+	columns := sortColumnsFor(typ)
+	for i, c := range columns {
+		if c.sortType == current {
+			return columns[(i+1)%len(columns)].sortType
+		}
+	}
+	return columns[0].sortType
+}
+
+// IsSortable reports whether the table can be sorted by the sort type.
+func IsSortable(typ string, sortType SortType) bool {
+	for _, c := range sortColumnsFor(typ) {
+		if c.sortType == sortType {
+			return true
+		}
+	}
+	return false
+}
+
+// markHeader returns a copy of the header with an arrow on the sorted column.
+func markHeader(header []string, columns []sortColumn, sortType SortType, reverse bool) []string {
+	marked := make([]string, len(header))
+	copy(marked, header)
+	for _, c := range columns {
+		if c.sortType == sortType {
+			if isDescending(sortType, reverse) {
+				marked[c.index] += "▼"
+			} else {
+				marked[c.index] += "▲"
+			}
+		}
+	}
+	return marked
+}
+
+// isDescending reports the order of rows. Names are sorted ascending and
+// values descending by default, so the top consumers come first.
+func isDescending(sortType SortType, reverse bool) bool {
+	return (sortType != ByName) != reverse
+}
+
+// lessBy compares two rows by their values for the sort type, and falls back
+// to their names if the values are equal.
+func lessBy(sortType SortType, reverse bool, x, y float64, xNames, yNames []string) bool {
+	if sortType != ByName && x != y {
+		return (x < y) != isDescending(sortType, reverse)
+	}
+	if sortType == ByName && reverse {
+		return lessNames(yNames, xNames)
+	}
+	return lessNames(xNames, yNames)
+}
+
+// finiteOrZero keeps NaN and Inf, e.g. percentages of nothing allocatable,
+// from breaking the ordering of rows.
+func finiteOrZero(v float64) float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	return v
+}
+
+func lessNames(x, y []string) bool {
+	for i := 0; i < len(x) && i < len(y); i++ {
+		if x[i] != y[i] {
+			return x[i] < y[i]
+		}
 	}
+	return len(x) < len(y)
 }
 
-type sortByName []*Resource
+func AsAllTableViewer(resources []*Resource, sortType SortType, reverse bool) ResourceTableViewer {
+	lists := make([]corev1.ResourceList, 0, len(resources)*2)
+	for _, r := range resources {
+		lists = append(lists, r.limits, r.requests)
+	}
+	return &allTableViewer{
+		resources: resources,
+		extra:     extraResourceNames(lists...),
+		sortType:  sortType,
+		reverse:   reverse,
+	}
+}
 
-func (s sortByName) GetTableShape(rect image.Rectangle) (string, []string, []int, [][]string) {
-	rows := make([][]string, len(s))
-	var maxLen0, maxLen1 int
-	for i, v := range s {
-		rows[i] = v.toRow()
+type allTableViewer struct {
+	resources []*Resource
+	// resources other than cpu and memory, which are put on the right
+	extra    []corev1.ResourceName
+	sortType SortType
+	reverse  bool
+}
+
+func (s *allTableViewer) GetTableShape(rect image.Rectangle) (string, []string, []int, [][]string) {
+	rows := make([][]string, len(s.resources))
+	var maxLen0, maxLen1, maxLen2 int
+	for i, v := range s.resources {
+		rows[i] = append(v.toRow(), v.extraRow(s.extra)...)
 		maxLen0 = IntMax(maxLen0, len(rows[i][0]))
 		maxLen1 = IntMax(maxLen1, len(rows[i][1]))
+		maxLen2 = IntMax(maxLen2, len(rows[i][2]))
 	}
+	extra := extraHeader(s.extra, allExtraSuffixes...)
 	title, header, widths :=
-		allTitle, allHeader, allWidthFn(rect, maxLen0, maxLen1)
+		allTitle,
+		withExtra(markHeader(allHeader, allSortColumns, s.sortType, s.reverse), extra),
+		append(allWidthFn(rect, maxLen0, maxLen1, maxLen2), extraWidths(extra)...)
 
-	if len(s) == 0 {
+	if len(s.resources) == 0 {
 		header = emptyHeader
 		widths = emptyWidthFn(rect)
 		rows = emptyRows
@@ -99,14 +333,31 @@ func (s sortByName) GetTableShape(rect image.Rectangle) (string, []string, []int
 	return title, header, widths, rows
 }
 
-func (s sortByName) SortRows() {
-	sort.Slice(s, func(i, j int) bool {
-		if s[i].podName < s[j].podName {
-			return true
-		}
-		if s[i].podName > s[j].podName {
-			return false
-		}
-		return s[i].containerName < s[j].containerName
+func (s *allTableViewer) GetHeader() []string {
+	return withExtra(allHeader, extraHeader(s.extra, allExtraSuffixes...))
+}
+
+func (s *allTableViewer) GetRows() [][]string {
+	rows := make([][]string, len(s.resources))
+	for i, v := range s.resources {
+		rows[i] = append(v.toRow(), v.extraRow(s.extra)...)
+	}
+	return rows
+}
+
+func (s *allTableViewer) GetLevels(t Thresholds) [][]Level {
+	levels := make([][]Level, len(s.resources))
+	for i, v := range s.resources {
+		levels[i] = v.levels(t)
+	}
+	return levels
+}
+
+func (s *allTableViewer) SortRows() {
+	sort.SliceStable(s.resources, func(i, j int) bool {
+		x, y := s.resources[i], s.resources[j]
+		return lessBy(s.sortType, s.reverse,
+			x.sortValue(s.sortType), y.sortValue(s.sortType),
+			x.names(), y.names())
 	})
 }
@@ -0,0 +1,45 @@
+package resource
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+func TestSummarizedWidthFn(t *testing.T) {
+	tests := []struct {
+		width int
+		want  []int
+	}{
+		{width: 200, want: []int{40, 54, 10, 10, 10, 11, 11, 10, 10, 10, 11, 11}},
+		// without the percentages
+		{width: 120, want: []int{24, 36, 10, 10, 10, 0, 0, 10, 10, 10, 0, 0}},
+		// and without the requests and limits
+		{width: 80, want: []int{16, 44, 10, 0, 0, 0, 0, 10, 0, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		got := summarizedWidthFn(image.Rect(0, 0, tt.width, 10), 50, 50)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: got %v, want %v", tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestNodeWidthFn(t *testing.T) {
+	tests := []struct {
+		width int
+		want  []int
+	}{
+		{width: 200, want: []int{54, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 11, 11}},
+		// without the percentages of the requests and limits
+		{width: 140, want: []int{40, 10, 10, 10, 10, 10, 0, 0, 10, 10, 10, 10, 10, 0, 0}},
+		// and without the requests and limits
+		{width: 120, want: []int{54, 10, 10, 0, 0, 10, 0, 0, 10, 10, 0, 0, 10, 0, 0}},
+	}
+	for _, tt := range tests {
+		got := nodeWidthFn(image.Rect(0, 0, tt.width, 10), 50)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: got %v, want %v", tt.width, got, tt.want)
+		}
+	}
+}
@@ -2,22 +2,71 @@ package resource
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	kr "k8s.io/apimachinery/pkg/api/resource"
 
 	. "github.com/ynqa/ktop/pkg/util"
 )
 
 type SummarizedResource struct {
-	podName  string
-	nodeName string
-	usage    corev1.ResourceList
+	namespace string
+	podName   string
+	nodeName  string
+	usage     corev1.ResourceList
+	requests  corev1.ResourceList
+	limits    corev1.ResourceList
+	pod       *Pod
 }
 
-func NewSummarizedResource(p corev1.Pod, sumUsage corev1.ResourceList) *SummarizedResource {
+// NewSummarizedResource sums up the containers of the pod.
+func NewSummarizedResource(p *Pod, containers []*Resource) *SummarizedResource {
+	usages := make([]corev1.ResourceList, len(containers))
+	requests := make([]corev1.ResourceList, len(containers))
+	limits := make([]corev1.ResourceList, len(containers))
+	for i, c := range containers {
+		usages[i], requests[i], limits[i] = c.usage, c.requests, c.limits
+	}
+	// no containers matched use nothing
+	usage := sumResourceLists(usages, false)
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if _, ok := usage[name]; !ok {
+			usage[name] = kr.Quantity{}
+		}
+	}
 	return &SummarizedResource{
-		podName:  p.Name,
-		nodeName: p.Spec.NodeName,
-		usage:    sumUsage,
+		namespace: p.pod.Namespace,
+		podName:   p.pod.Name,
+		nodeName:  p.pod.Spec.NodeName,
+		usage:     usage,
+		requests:  sumResourceLists(requests, false),
+		limits:    sumResourceLists(limits, true),
+		pod:       p,
+	}
+}
+
+// sumResourceLists sums cpu and memory of the lists, which must all have them if bounded, e.g. limits.
+func sumResourceLists(lists []corev1.ResourceList, bounded bool) corev1.ResourceList {
+	sum := corev1.ResourceList{}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		var (
+			total kr.Quantity
+			found int
+		)
+		for _, lst := range lists {
+			if q, ok := lst[name]; ok {
+				total.Add(q)
+				found++
+			}
+		}
+		if found == 0 || (bounded && found < len(lists)) {
+			continue
+		}
+		sum[name] = total
 	}
+	return sum
+}
+
+func (s *SummarizedResource) GetNamespace() string {
+	return s.namespace
 }
 
 func (s *SummarizedResource) GetNodeName() string {
@@ -28,6 +77,10 @@ func (s *SummarizedResource) GetPodName() string {
 	return s.podName
 }
 
+func (s *SummarizedResource) GetPod() *Pod {
+	return s.pod
+}
+
 func (s *SummarizedResource) GetCpuUsage() (float64, string) {
 	return GetResourceValue(s.usage, corev1.ResourceCPU),
 		GetResourceValueString(s.usage, corev1.ResourceCPU)
@@ -38,11 +91,109 @@ func (s *SummarizedResource) GetMemoryUsage() (float64, string) {
 		GetResourceValueString(s.usage, corev1.ResourceMemory)
 }
 
-// header: "POD", "%CPU", "%MEM"
+func (s *SummarizedResource) GetCpuRequests() (float64, string, bool) {
+	_, ok := s.requests[corev1.ResourceCPU]
+	str := GetResourceValueString(s.requests, corev1.ResourceCPU)
+	return GetResourceValue(s.requests, corev1.ResourceCPU), str, ok
+}
+
+func (s *SummarizedResource) GetMemoryRequests() (float64, string, bool) {
+	_, ok := s.requests[corev1.ResourceMemory]
+	str := GetResourceValueString(s.requests, corev1.ResourceMemory)
+	return GetResourceValue(s.requests, corev1.ResourceMemory), str, ok
+}
+
+func (s *SummarizedResource) GetCpuLimits() (float64, string, bool) {
+	_, ok := s.limits[corev1.ResourceCPU]
+	str := GetResourceValueString(s.limits, corev1.ResourceCPU)
+	return GetResourceValue(s.limits, corev1.ResourceCPU), str, ok
+}
+
+func (s *SummarizedResource) GetMemoryLimits() (float64, string, bool) {
+	_, ok := s.limits[corev1.ResourceMemory]
+	str := GetResourceValueString(s.limits, corev1.ResourceMemory)
+	return GetResourceValue(s.limits, corev1.ResourceMemory), str, ok
+}
+
+// Key identifies the pod across namespaces.
+func (s *SummarizedResource) Key() string {
+	return "pod/" + s.namespace + "/" + s.podName
+}
+
+func (s *SummarizedResource) names() []string {
+	return []string{s.namespace, s.podName}
+}
+
+func (s *SummarizedResource) sortValue(sortType SortType) float64 {
+	switch sortType {
+	case ByCpuUsage:
+		return GetResourceValue(s.usage, corev1.ResourceCPU)
+	case ByCpuRequests:
+		return GetResourceValue(s.requests, corev1.ResourceCPU)
+	case ByCpuLimits:
+		return GetResourceValue(s.limits, corev1.ResourceCPU)
+	case ByCpuRequestPercentage:
+		return percentage(s.usage, s.requests, corev1.ResourceCPU)
+	case ByCpuLimitPercentage:
+		return percentage(s.usage, s.limits, corev1.ResourceCPU)
+	case ByMemoryUsage:
+		return GetResourceValue(s.usage, corev1.ResourceMemory)
+	case ByMemoryRequests:
+		return GetResourceValue(s.requests, corev1.ResourceMemory)
+	case ByMemoryLimits:
+		return GetResourceValue(s.limits, corev1.ResourceMemory)
+	case ByMemoryRequestPercentage:
+		return percentage(s.usage, s.requests, corev1.ResourceMemory)
+	case ByMemoryLimitPercentage:
+		return percentage(s.usage, s.limits, corev1.ResourceMemory)
+	default:
+		return 0
+	}
+}
+
+// levels colors the percentages of the limits.
+func (s *SummarizedResource) levels(t Thresholds) []Level {
+	return t.usageLevels(len(summarizedHeader), s.usage, s.limits, 6, 11)
+}
+
+// Level returns how close the usage is to the limits.
+func (s *SummarizedResource) Level(t Thresholds) Level {
+	return maxLevel(s.levels(t))
+}
+
+// header: "NAMESPACE", "POD",
+// "CPU(U)", "CPU(R)", "CPU(L)", "%CPU(R)", "%CPU(L)",
+// "Memory(U)", "Memory(R)", "Memory(L)", "%Memory(R)", "%Memory(L)"
 func (s *SummarizedResource) toRow() []string {
 	return []string{
+		s.namespace,
 		s.podName,
 		GetResourceValueString(s.usage, corev1.ResourceCPU),
+		GetResourceValueString(s.requests, corev1.ResourceCPU),
+		GetResourceValueString(s.limits, corev1.ResourceCPU),
+		percentageString(s.usage, s.requests, corev1.ResourceCPU),
+		percentageString(s.usage, s.limits, corev1.ResourceCPU),
 		GetResourceValueString(s.usage, corev1.ResourceMemory),
+		GetResourceValueString(s.requests, corev1.ResourceMemory),
+		GetResourceValueString(s.limits, corev1.ResourceMemory),
+		percentageString(s.usage, s.requests, corev1.ResourceMemory),
+		percentageString(s.usage, s.limits, corev1.ResourceMemory),
+	}
+}
+
+// percentage returns the resource of x in percent of the one of y, or 0 if y does not have it.
+func percentage(x, y corev1.ResourceList, name corev1.ResourceName) float64 {
+	q, ok := y[name]
+	if !ok || q.IsZero() {
+		return 0
+	}
+	return GetResourcePercentage(x[name], q)
+}
+
+func percentageString(x, y corev1.ResourceList, name corev1.ResourceName) string {
+	q, ok := y[name]
+	if !ok || q.IsZero() {
+		return "-"
 	}
+	return GetResourcePercentageString(x[name], q)
 }
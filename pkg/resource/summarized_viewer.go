@@ -10,36 +10,57 @@ import (
 var (
 	summarizedTitle  = "⎈ Pod ⎈"
 	summarizedHeader = []string{
-		"POD", "CPU(U)", "Memory(U)",
+		"NAMESPACE", "POD",
+		"CPU(U)", "CPU(R)", "CPU(L)", "%CPU(R)", "%CPU(L)",
+		"Memory(U)", "Memory(R)", "Memory(L)", "%Memory(R)", "%Memory(L)",
 	}
-	summarizedWidthFn = func(rect image.Rectangle, maxLen int) []int {
-		nameWidth := IntMax(50, IntMin(rect.Dx()-20, maxLen+indentSize))
-		return []int{nameWidth, 10, 10}
+	// the percentages are hidden first on narrow terminals, and then the requests and limits
+	summarizedWidthFn = func(rect image.Rectangle, maxLen0, maxLen1 int) []int {
+		namespaceWidth := namespaceWidthFn(rect, maxLen0)
+		widths := fitWidthsFn(rect.Dx()-namespaceWidth-30,
+			[]int{10, 10, 10, 11, 11, 10, 10, 10, 11, 11},
+			[]int{3, 4, 8, 9}, []int{1, 2, 6, 7},
+		)
+		nameWidth := IntMax(30, IntMin(rect.Dx()-namespaceWidth-IntSum(widths...), maxLen1+indentSize))
+		return append([]int{namespaceWidth, nameWidth}, widths...)
+	}
+	summarizedSortColumns = []sortColumn{
+		{ByName, 0},
+		{ByCpuUsage, 2}, {ByCpuRequests, 3}, {ByCpuLimits, 4},
+		{ByCpuRequestPercentage, 5}, {ByCpuLimitPercentage, 6},
+		{ByMemoryUsage, 7}, {ByMemoryRequests, 8}, {ByMemoryLimits, 9},
+		{ByMemoryRequestPercentage, 10}, {ByMemoryLimitPercentage, 11},
 	}
 )
 
-func AsSummarizedTableViewer(resources []*SummarizedResource, sortType SortType) ResourceTableViewer {
-	switch sortType {
-	case ByName:
-		return sortByNameForSummarized(resources)
-	default:
-		return sortByNameForSummarized(resources)
+func AsSummarizedTableViewer(resources []*SummarizedResource, sortType SortType, reverse bool) ResourceTableViewer {
+	return &summarizedTableViewer{
+		resources: resources,
+		sortType:  sortType,
+		reverse:   reverse,
 	}
 }
 
-type sortByNameForSummarized []*SummarizedResource
+type summarizedTableViewer struct {
+	resources []*SummarizedResource
+	sortType  SortType
+	reverse   bool
+}
 
-func (s sortByNameForSummarized) GetTableShape(rect image.Rectangle) (string, []string, []int, [][]string) {
-	rows := make([][]string, len(s))
-	var maxLen int
-	for i, v := range s {
+func (s *summarizedTableViewer) GetTableShape(rect image.Rectangle) (string, []string, []int, [][]string) {
+	rows := make([][]string, len(s.resources))
+	var maxLen0, maxLen1 int
+	for i, v := range s.resources {
 		rows[i] = v.toRow()
-		maxLen = IntMax(maxLen, len(rows[i][0]))
+		maxLen0 = IntMax(maxLen0, len(rows[i][0]))
+		maxLen1 = IntMax(maxLen1, len(rows[i][1]))
 	}
 	title, header, widths :=
-		summarizedTitle, summarizedHeader, summarizedWidthFn(rect, maxLen)
+		summarizedTitle,
+		markHeader(summarizedHeader, summarizedSortColumns, s.sortType, s.reverse),
+		summarizedWidthFn(rect, maxLen0, maxLen1)
 
-	if len(s) == 0 {
+	if len(s.resources) == 0 {
 		header = emptyHeader
 		widths = emptyWidthFn(rect)
 		rows = emptyRows
@@ -47,8 +68,31 @@ func (s sortByNameForSummarized) GetTableShape(rect image.Rectangle) (string, []
 	return title, header, widths, rows
 }
 
-func (s sortByNameForSummarized) SortRows() {
-	sort.Slice(s, func(i, j int) bool {
-		return s[i].podName < s[j].podName
+func (s *summarizedTableViewer) GetHeader() []string {
+	return summarizedHeader
+}
+
+func (s *summarizedTableViewer) GetRows() [][]string {
+	rows := make([][]string, len(s.resources))
+	for i, v := range s.resources {
+		rows[i] = v.toRow()
+	}
+	return rows
+}
+
+func (s *summarizedTableViewer) GetLevels(t Thresholds) [][]Level {
+	levels := make([][]Level, len(s.resources))
+	for i, v := range s.resources {
+		levels[i] = v.levels(t)
+	}
+	return levels
+}
+
+func (s *summarizedTableViewer) SortRows() {
+	sort.SliceStable(s.resources, func(i, j int) bool {
+		x, y := s.resources[i], s.resources[j]
+		return lessBy(s.sortType, s.reverse,
+			x.sortValue(s.sortType), y.sortValue(s.sortType),
+			x.names(), y.names())
 	})
 }
@@ -0,0 +1,262 @@
+package resource
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kr "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/ynqa/ktop/pkg/util"
+)
+
+// WorkloadResource sums up the pods of a controller, e.g. a deployment.
+type WorkloadResource struct {
+	namespace string
+	kind      string
+	name      string
+	pods      []*SummarizedResource
+	usage     corev1.ResourceList
+	// usage per replica
+	avgUsage corev1.ResourceList
+	maxUsage corev1.ResourceList
+	requests corev1.ResourceList
+	limits   corev1.ResourceList
+}
+
+// NewWorkloadResources groups the pods by their controllers. Replica sets may be nil if unknown.
+func NewWorkloadResources(pods []*SummarizedResource, replicaSets *appsv1.ReplicaSetList) []*WorkloadResource {
+	replicaSetsByName := make(map[string]*appsv1.ReplicaSet)
+	if replicaSets != nil {
+		for i, rs := range replicaSets.Items {
+			replicaSetsByName[rs.Namespace+"/"+rs.Name] = &replicaSets.Items[i]
+		}
+	}
+	keys, grouped := groupPods(pods, func(p *SummarizedResource) string {
+		kind, name := workloadOf(p.pod, replicaSetsByName)
+		return p.namespace + "/" + kind + "/" + name
+	})
+	resources := make([]*WorkloadResource, len(keys))
+	for i, key := range keys {
+		pods := grouped[key]
+		kind, name := workloadOf(pods[0].pod, replicaSetsByName)
+		resources[i] = newWorkloadResource(pods[0].namespace, kind, name, pods)
+	}
+	return resources
+}
+
+func newWorkloadResource(namespace, kind, name string, pods []*SummarizedResource) *WorkloadResource {
+	usage, requests, limits := sumPods(pods)
+	maxUsage := corev1.ResourceList{}
+	for _, p := range pods {
+		maxResourceList(maxUsage, p.usage)
+	}
+	cpu, memory := usage[corev1.ResourceCPU], usage[corev1.ResourceMemory]
+	replicas := int64(len(pods))
+	return &WorkloadResource{
+		namespace: namespace,
+		kind:      kind,
+		name:      name,
+		pods:      pods,
+		usage:     usage,
+		avgUsage: corev1.ResourceList{
+			corev1.ResourceCPU:    *kr.NewMilliQuantity(cpu.MilliValue()/replicas, kr.DecimalSI),
+			corev1.ResourceMemory: *kr.NewQuantity(memory.Value()/replicas, kr.BinarySI),
+		},
+		maxUsage: maxUsage,
+		requests: requests,
+		limits:   limits,
+	}
+}
+
+// workloadOf returns the kind and the name of the controller of the pod, or of the pod itself.
+func workloadOf(p *Pod, replicaSets map[string]*appsv1.ReplicaSet) (string, string) {
+	ref := controllerOf(p.pod.OwnerReferences)
+	if ref == nil {
+		return "Pod", p.pod.Name
+	}
+	if ref.Kind != "ReplicaSet" {
+		return ref.Kind, ref.Name
+	}
+	if rs, ok := replicaSets[p.pod.Namespace+"/"+ref.Name]; ok {
+		if owner := controllerOf(rs.OwnerReferences); owner != nil {
+			return owner.Kind, owner.Name
+		}
+		return ref.Kind, ref.Name
+	}
+	// replica sets of deployments are named after them and the hash on the pods, if unknown
+	if hash, ok := p.pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; ok && strings.HasSuffix(ref.Name, "-"+hash) {
+		return "Deployment", strings.TrimSuffix(ref.Name, "-"+hash)
+	}
+	return ref.Kind, ref.Name
+}
+
+func controllerOf(refs []metav1.OwnerReference) *metav1.OwnerReference {
+	for i, ref := range refs {
+		if ref.Controller != nil && *ref.Controller {
+			return &refs[i]
+		}
+	}
+	return nil
+}
+
+func (w *WorkloadResource) GetNamespace() string {
+	return w.namespace
+}
+
+// GetWorkloadName returns the kind and the name, e.g. Deployment/nginx.
+func (w *WorkloadResource) GetWorkloadName() string {
+	return w.kind + "/" + w.name
+}
+
+func (w *WorkloadResource) GetReplicas() int {
+	return len(w.pods)
+}
+
+func (w *WorkloadResource) GetCpuUsage() (float64, string) {
+	return GetResourceValue(w.usage, corev1.ResourceCPU),
+		GetResourceValueString(w.usage, corev1.ResourceCPU)
+}
+
+func (w *WorkloadResource) GetMemoryUsage() (float64, string) {
+	return GetResourceValue(w.usage, corev1.ResourceMemory),
+		GetResourceValueString(w.usage, corev1.ResourceMemory)
+}
+
+func (w *WorkloadResource) GetCpuRequests() (float64, string, bool) {
+	_, ok := w.requests[corev1.ResourceCPU]
+	str := GetResourceValueString(w.requests, corev1.ResourceCPU)
+	return GetResourceValue(w.requests, corev1.ResourceCPU), str, ok
+}
+
+func (w *WorkloadResource) GetMemoryRequests() (float64, string, bool) {
+	_, ok := w.requests[corev1.ResourceMemory]
+	str := GetResourceValueString(w.requests, corev1.ResourceMemory)
+	return GetResourceValue(w.requests, corev1.ResourceMemory), str, ok
+}
+
+func (w *WorkloadResource) GetCpuLimits() (float64, string, bool) {
+	_, ok := w.limits[corev1.ResourceCPU]
+	str := GetResourceValueString(w.limits, corev1.ResourceCPU)
+	return GetResourceValue(w.limits, corev1.ResourceCPU), str, ok
+}
+
+func (w *WorkloadResource) GetMemoryLimits() (float64, string, bool) {
+	_, ok := w.limits[corev1.ResourceMemory]
+	str := GetResourceValueString(w.limits, corev1.ResourceMemory)
+	return GetResourceValue(w.limits, corev1.ResourceMemory), str, ok
+}
+
+// Key identifies the workload across namespaces.
+func (w *WorkloadResource) Key() string {
+	return "workload/" + w.namespace + "/" + w.kind + "/" + w.name
+}
+
+func (w *WorkloadResource) names() []string {
+	return []string{w.namespace, w.kind, w.name}
+}
+
+func (w *WorkloadResource) sortValue(sortType SortType) float64 {
+	switch sortType {
+	case ByReplicas:
+		return float64(len(w.pods))
+	case ByCpuUsage:
+		return GetResourceValue(w.usage, corev1.ResourceCPU)
+	case ByCpuAverage:
+		return GetResourceValue(w.avgUsage, corev1.ResourceCPU)
+	case ByCpuMax:
+		return GetResourceValue(w.maxUsage, corev1.ResourceCPU)
+	case ByCpuRequests:
+		return GetResourceValue(w.requests, corev1.ResourceCPU)
+	case ByCpuLimits:
+		return GetResourceValue(w.limits, corev1.ResourceCPU)
+	case ByCpuLimitPercentage:
+		return percentage(w.usage, w.limits, corev1.ResourceCPU)
+	case ByMemoryUsage:
+		return GetResourceValue(w.usage, corev1.ResourceMemory)
+	case ByMemoryAverage:
+		return GetResourceValue(w.avgUsage, corev1.ResourceMemory)
+	case ByMemoryMax:
+		return GetResourceValue(w.maxUsage, corev1.ResourceMemory)
+	case ByMemoryRequests:
+		return GetResourceValue(w.requests, corev1.ResourceMemory)
+	case ByMemoryLimits:
+		return GetResourceValue(w.limits, corev1.ResourceMemory)
+	case ByMemoryLimitPercentage:
+		return percentage(w.usage, w.limits, corev1.ResourceMemory)
+	default:
+		return 0
+	}
+}
+
+// levels colors the percentages of the limits.
+func (w *WorkloadResource) levels(t Thresholds) []Level {
+	return t.usageLevels(len(workloadHeader), w.usage, w.limits, 8, 14)
+}
+
+// Level returns how close the usage is to the limits.
+func (w *WorkloadResource) Level(t Thresholds) Level {
+	return maxLevel(w.levels(t))
+}
+
+// header: "NAMESPACE", "WORKLOAD", "REPLICAS",
+// "CPU(U)", "CPU(AVG)", "CPU(MAX)", "CPU(R)", "CPU(L)", "%CPU(L)",
+// "Memory(U)", "Memory(AVG)", "Memory(MAX)", "Memory(R)", "Memory(L)", "%Memory(L)"
+func (w *WorkloadResource) toRow() []string {
+	return []string{
+		w.namespace,
+		w.GetWorkloadName(),
+		fmt.Sprint(len(w.pods)),
+		GetResourceValueString(w.usage, corev1.ResourceCPU),
+		GetResourceValueString(w.avgUsage, corev1.ResourceCPU),
+		GetResourceValueString(w.maxUsage, corev1.ResourceCPU),
+		GetResourceValueString(w.requests, corev1.ResourceCPU),
+		GetResourceValueString(w.limits, corev1.ResourceCPU),
+		percentageString(w.usage, w.limits, corev1.ResourceCPU),
+		GetResourceValueString(w.usage, corev1.ResourceMemory),
+		GetResourceValueString(w.avgUsage, corev1.ResourceMemory),
+		GetResourceValueString(w.maxUsage, corev1.ResourceMemory),
+		GetResourceValueString(w.requests, corev1.ResourceMemory),
+		GetResourceValueString(w.limits, corev1.ResourceMemory),
+		percentageString(w.usage, w.limits, corev1.ResourceMemory),
+	}
+}
+
+// Describe shows the workload with its replicas.
+func (w *WorkloadResource) Describe(now time.Time) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Name: %v\n", w.name)
+	fmt.Fprintf(&buf, "Namespace: %v\n", w.namespace)
+	fmt.Fprintf(&buf, "Kind: %v\n", w.kind)
+	fmt.Fprintf(&buf, "Replicas: %v\n", len(w.pods))
+	buf.WriteString("\n")
+
+	pods := make([]*SummarizedResource, len(w.pods))
+	copy(pods, w.pods)
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].podName < pods[j].podName
+	})
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join([]string{
+		"POD", "NODE", "PHASE", "AGE", "RESTARTS", "CPU(U)", "Memory(U)",
+	}, "\t"))
+	for _, p := range pods {
+		fmt.Fprintln(tw, strings.Join([]string{
+			p.podName,
+			orNone(p.nodeName),
+			orNone(string(p.pod.pod.Status.Phase)),
+			age(p.pod.pod.CreationTimestamp.Time, now),
+			fmt.Sprint(p.pod.restarts()),
+			GetResourceValueString(p.usage, corev1.ResourceCPU),
+			GetResourceValueString(p.usage, corev1.ResourceMemory),
+		}, "\t"))
+	}
+	tw.Flush()
+	return buf.String()
+}
@@ -0,0 +1,125 @@
+package resource
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/metrics/pkg/apis/metrics"
+)
+
+func controllerRef(kind, name string) metav1.OwnerReference {
+	controller := true
+	return metav1.OwnerReference{Kind: kind, Name: name, Controller: &controller}
+}
+
+func TestWorkloadOf(t *testing.T) {
+	replicaSets := map[string]*appsv1.ReplicaSet{
+		"default/web-5d9c8f": {
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       "default",
+				Name:            "web-5d9c8f",
+				OwnerReferences: []metav1.OwnerReference{controllerRef("Deployment", "web")},
+			},
+		},
+		"default/orphan": {
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "orphan"},
+		},
+	}
+	tests := []struct {
+		name   string
+		labels map[string]string
+		refs   []metav1.OwnerReference
+		kind   string
+		wname  string
+	}{
+		{
+			name:  "without controller",
+			kind:  "Pod",
+			wname: "pod",
+		},
+		{
+			name:  "owner but not controller",
+			refs:  []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-5d9c8f"}},
+			kind:  "Pod",
+			wname: "pod",
+		},
+		{
+			name:  "stateful set",
+			refs:  []metav1.OwnerReference{controllerRef("StatefulSet", "db")},
+			kind:  "StatefulSet",
+			wname: "db",
+		},
+		{
+			name:  "deployment through the replica set",
+			refs:  []metav1.OwnerReference{controllerRef("ReplicaSet", "web-5d9c8f")},
+			kind:  "Deployment",
+			wname: "web",
+		},
+		{
+			name:  "replica set without controller",
+			refs:  []metav1.OwnerReference{controllerRef("ReplicaSet", "orphan")},
+			kind:  "ReplicaSet",
+			wname: "orphan",
+		},
+		{
+			name:   "deployment by the template hash",
+			labels: map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "7f6b4c"},
+			refs:   []metav1.OwnerReference{controllerRef("ReplicaSet", "api-7f6b4c")},
+			kind:   "Deployment",
+			wname:  "api",
+		},
+		{
+			name:   "replica set not matching the template hash",
+			labels: map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "7f6b4c"},
+			refs:   []metav1.OwnerReference{controllerRef("ReplicaSet", "api")},
+			kind:   "ReplicaSet",
+			wname:  "api",
+		},
+		{
+			name:  "replica set without the template hash",
+			refs:  []metav1.OwnerReference{controllerRef("ReplicaSet", "api-7f6b4c")},
+			kind:  "ReplicaSet",
+			wname: "api-7f6b4c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := NewPod(corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:       "default",
+					Name:            "pod",
+					Labels:          tt.labels,
+					OwnerReferences: tt.refs,
+				},
+			}, metrics.PodMetrics{})
+			kind, name := workloadOf(pod, replicaSets)
+			if kind != tt.kind || name != tt.wname {
+				t.Errorf("got %v/%v, want %v/%v", kind, name, tt.kind, tt.wname)
+			}
+		})
+	}
+}
+
+func TestWorkloadOfReplicaSetInOtherNamespace(t *testing.T) {
+	replicaSets := map[string]*appsv1.ReplicaSet{
+		"other/web-5d9c8f": {
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       "other",
+				Name:            "web-5d9c8f",
+				OwnerReferences: []metav1.OwnerReference{controllerRef("Deployment", "other-web")},
+			},
+		},
+	}
+	pod := NewPod(corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "pod",
+			OwnerReferences: []metav1.OwnerReference{controllerRef("ReplicaSet", "web-5d9c8f")},
+		},
+	}, metrics.PodMetrics{})
+	if kind, name := workloadOf(pod, replicaSets); kind != "ReplicaSet" || name != "web-5d9c8f" {
+		t.Errorf("got %v/%v, want ReplicaSet/web-5d9c8f", kind, name)
+	}
+}
@@ -0,0 +1,93 @@
+package resource
+
+import (
+	"image"
+	"sort"
+
+	. "github.com/ynqa/ktop/pkg/util"
+)
+
+var (
+	workloadTitle  = "⎈ Workload ⎈"
+	workloadHeader = []string{
+		"NAMESPACE", "WORKLOAD", "REPLICAS",
+		"CPU(U)", "CPU(AVG)", "CPU(MAX)", "CPU(R)", "CPU(L)", "%CPU(L)",
+		"Memory(U)", "Memory(AVG)", "Memory(MAX)", "Memory(R)", "Memory(L)", "%Memory(L)",
+	}
+	workloadWidthFn = func(rect image.Rectangle, maxLen0, maxLen1 int) []int {
+		namespaceWidth := namespaceWidthFn(rect, maxLen0)
+		nameWidth := IntMax(40, IntMin(rect.Dx()-namespaceWidth-145, maxLen1+indentSize))
+		return []int{namespaceWidth, nameWidth, 10, 10, 10, 10, 10, 10, 10, 10, 12, 12, 10, 10, 11}
+	}
+	workloadSortColumns = []sortColumn{
+		{ByName, 0}, {ByReplicas, 2},
+		{ByCpuUsage, 3}, {ByCpuAverage, 4}, {ByCpuMax, 5},
+		{ByCpuRequests, 6}, {ByCpuLimits, 7}, {ByCpuLimitPercentage, 8},
+		{ByMemoryUsage, 9}, {ByMemoryAverage, 10}, {ByMemoryMax, 11},
+		{ByMemoryRequests, 12}, {ByMemoryLimits, 13}, {ByMemoryLimitPercentage, 14},
+	}
+)
+
+func AsWorkloadTableViewer(resources []*WorkloadResource, sortType SortType, reverse bool) ResourceTableViewer {
+	return &workloadTableViewer{
+		resources: resources,
+		sortType:  sortType,
+		reverse:   reverse,
+	}
+}
+
+type workloadTableViewer struct {
+	resources []*WorkloadResource
+	sortType  SortType
+	reverse   bool
+}
+
+func (s *workloadTableViewer) GetTableShape(rect image.Rectangle) (string, []string, []int, [][]string) {
+	rows := make([][]string, len(s.resources))
+	var maxLen0, maxLen1 int
+	for i, v := range s.resources {
+		rows[i] = v.toRow()
+		maxLen0 = IntMax(maxLen0, len(rows[i][0]))
+		maxLen1 = IntMax(maxLen1, len(rows[i][1]))
+	}
+	title, header, widths :=
+		workloadTitle,
+		markHeader(workloadHeader, workloadSortColumns, s.sortType, s.reverse),
+		workloadWidthFn(rect, maxLen0, maxLen1)
+
+	if len(s.resources) == 0 {
+		header = emptyHeader
+		widths = emptyWidthFn(rect)
+		rows = emptyRows
+	}
+	return title, header, widths, rows
+}
+
+func (s *workloadTableViewer) GetHeader() []string {
+	return workloadHeader
+}
+
+func (s *workloadTableViewer) GetRows() [][]string {
+	rows := make([][]string, len(s.resources))
+	for i, v := range s.resources {
+		rows[i] = v.toRow()
+	}
+	return rows
+}
+
+func (s *workloadTableViewer) GetLevels(t Thresholds) [][]Level {
+	levels := make([][]Level, len(s.resources))
+	for i, v := range s.resources {
+		levels[i] = v.levels(t)
+	}
+	return levels
+}
+
+func (s *workloadTableViewer) SortRows() {
+	sort.SliceStable(s.resources, func(i, j int) bool {
+		x, y := s.resources[i], s.resources[j]
+		return lessBy(s.sortType, s.reverse,
+			x.sortValue(s.sortType), y.sortValue(s.sortType),
+			x.names(), y.names())
+	})
+}
@@ -2,94 +2,212 @@ package ui
 
 import (
 	"image"
+	"math"
+	"strings"
+	"time"
 
 	. "github.com/gizak/termui/v3"
 )
 
+const (
+	// columns between the time markers on the X axis
+	timeMarkerStep = 15
+)
+
+// Series is a line on the graph, with the entry on the legend.
+type Series struct {
+	Name string
+	// label on the legend after the name, e.g. the latest value
+	Label string
+	Data  []float64
+	Color Color
+}
+
 type Graph struct {
 	*Block
-	// plot data
-	Data           []float64
-	UpperLimit     float64
-	DrawUpperLimit bool
+	// plot data, drawn in order so that the last one is on the top,
+	// where NaN is a gap of the missed samples
+	Series     []Series
+	UpperLimit float64
+	// scale to the visible data of the last series instead of UpperLimit,
+	// so that small usage is not a flat line at the bottom
+	AutoScale bool
+
+	// axes, which are drawn if they are set.
+	// FormatValue formats the ticks on the Y axis with the units,
+	// and Interval is the time between the samples for the X axis.
+	FormatValue func(float64) string
+	Interval    time.Duration
 
 	// label
-	LabelHeader     string
-	LabelData       string
-	LabelUpperLimit string
+	LabelHeader string
 
 	// color
-	DataColor      Color
-	LimitColor     Color
 	LabelNameColor Color
+	AxisColor      Color
 }
 
 func NewGraph() *Graph {
 	return &Graph{
-		Block: NewBlock(),
-		Data:  make([]float64, 0),
+		Block:     NewBlock(),
+		Series:    make([]Series, 0),
+		AxisColor: ColorClear,
 	}
 }
 
 func (self *Graph) Reset() {
-	self.Data = make([]float64, 0)
+	self.Series = make([]Series, 0)
 	self.UpperLimit = 0
 	self.LabelHeader = ""
-	self.LabelData = ""
-	self.LabelUpperLimit = ""
 }
 
-func (self *Graph) calcHeight(val float64) int {
-	return int((val / self.UpperLimit) * float64(self.Inner.Dy()-5))
+// ConstantData returns the data of the value as long as the other data,
+// e.g. for limits drawn along with the usage.
+func ConstantData(value float64, length int) []float64 {
+	data := make([]float64, length)
+	for i := range data {
+		data[i] = value
+	}
+	return data
+}
+
+// autoUpperLimit returns the round number over the visible data of the last series.
+func (self *Graph) autoUpperLimit() float64 {
+	if len(self.Series) == 0 {
+		return 0
+	}
+	data := self.Series[len(self.Series)-1].Data
+	if len(data) > self.Inner.Dx() {
+		data = data[len(data)-self.Inner.Dx():]
+	}
+	max := 0.
+	for _, v := range data {
+		if !math.IsNaN(v) {
+			max = math.Max(max, v)
+		}
+	}
+	if max <= 0 {
+		return 0
+	}
+	return niceCeil(max)
+}
+
+// niceCeil rounds up the value to 1, 2, 2.5 or 5 times a power of 10.
+func niceCeil(v float64) float64 {
+	exp := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 2.5, 5, 10} {
+		if v <= m*exp {
+			return m * exp
+		}
+	}
+	return 10 * exp
+}
+
+// upperLimit returns the top of the graph to draw, which is UpperLimit
+// unless it scales to the data.
+func (self *Graph) upperLimit() float64 {
+	if self.AutoScale {
+		// keep UpperLimit if there is nothing to scale to, e.g. no usage yet
+		if upper := self.autoUpperLimit(); upper > 0 {
+			return upper
+		}
+	}
+	return self.UpperLimit
+}
+
+// plotRect returns the area to plot the data, inside the axes.
+func (self *Graph) plotRect(upper float64) image.Rectangle {
+	rect := self.Inner
+	if self.FormatValue != nil && upper > 0 {
+		rect.Min.X += self.yAxisWidth(upper)
+	}
+	if self.Interval > 0 {
+		rect.Max.Y--
+	}
+	return rect
+}
+
+func (self *Graph) yAxisWidth(upper float64) int {
+	width := 0
+	for _, tick := range yTicks(upper) {
+		if w := len(self.FormatValue(tick)) + 1; w > width {
+			width = w
+		}
+	}
+	return width
+}
+
+// yTicks returns the values on the Y axis from the bottom.
+func yTicks(upper float64) []float64 {
+	return []float64{0, upper / 2, upper}
+}
+
+func (self *Graph) maxHeight(plot image.Rectangle) int {
+	return plot.Dy() - 5
+}
+
+func (self *Graph) calcHeight(plot image.Rectangle, upper, val float64) int {
+	if upper <= 0 {
+		return 0
+	}
+	maxHeight := self.maxHeight(plot)
+	height := int((val / upper) * float64(maxHeight))
+	// values over the upper limit stick to the top
+	if height > maxHeight {
+		return maxHeight
+	}
+	if height < 0 {
+		return 0
+	}
+	return height
 }
 
 func (self *Graph) Draw(buf *Buffer) {
 	self.Block.Draw(buf)
+	upper := self.upperLimit()
+	plot := self.plotRect(upper)
+	if plot.Dx() <= 0 || self.maxHeight(plot) <= 0 {
+		return
+	}
 
 	// describe graph
-	if len(self.Data) != 0 {
-		canvas := NewCanvas()
-		canvas.Rectangle = self.Inner
-		// draw upper limit
-		if self.DrawUpperLimit {
-			limitHeight := self.calcHeight(self.UpperLimit)
-			canvas.SetLine(
-				image.Pt(
-					self.Inner.Min.X*2,
-					(self.Inner.Max.Y-limitHeight-1)*4,
-				),
-				image.Pt(
-					self.Inner.Max.X*2,
-					(self.Inner.Max.Y-limitHeight-1)*4,
-				),
-				self.LimitColor,
-			)
+	canvas := NewCanvas()
+	canvas.Rectangle = plot
+	for _, series := range self.Series {
+		if len(series.Data) == 0 {
+			continue
 		}
-
-		// use latest data
-		data := self.Data
-		if len(self.Data) > self.Inner.Dx() {
-			data = data[len(self.Data)-1-self.Inner.Dx() : len(self.Data)-1]
+		// use latest data, which is on the right edge
+		data := series.Data
+		if len(data) > plot.Dx() {
+			data = data[len(data)-plot.Dx():]
 		}
-		previousHeight := self.calcHeight(data[len(data)-1])
-		for i := len(data) - 1; i >= 0; i-- {
-			height := self.calcHeight(data[i])
-			// draw data
-			canvas.SetLine(
-				image.Pt(
-					(self.Inner.Min.X+i)*2,
-					(self.Inner.Max.Y-previousHeight-1)*4,
-				),
-				image.Pt(
-					(self.Inner.Min.X+i+1)*2,
-					(self.Inner.Max.Y-height-1)*4,
-				),
-				self.DataColor,
+		offset := plot.Max.X - len(data)
+		point := func(i int) image.Point {
+			return image.Pt(
+				(offset+i)*2,
+				(plot.Max.Y-self.calcHeight(plot, upper, data[i])-1)*4,
 			)
-			previousHeight = height
 		}
-		canvas.Draw(buf)
+		// samples without the neighbors are points
+		for i, v := range data {
+			if !math.IsNaN(v) && (i == 0 || math.IsNaN(data[i-1])) && (i == len(data)-1 || math.IsNaN(data[i+1])) {
+				canvas.SetLine(point(i), point(i), series.Color)
+			}
+		}
+		for i := 1; i < len(data); i++ {
+			if math.IsNaN(data[i-1]) || math.IsNaN(data[i]) {
+				continue
+			}
+			// lines over the upper limit are out of the graph
+			if data[i-1] > upper && data[i] > upper {
+				continue
+			}
+			canvas.SetLine(point(i-1), point(i), series.Color)
+		}
 	}
+	canvas.Draw(buf)
+	self.drawAxes(buf, plot, upper)
 
 	// describe labels
 	stage := 1
@@ -98,24 +216,66 @@ func (self *Graph) Draw(buf *Buffer) {
 			buf.SetString(
 				self.LabelHeader,
 				NewStyle(self.LabelNameColor, ColorClear, ModifierBold),
-				image.Pt(self.Inner.Min.X+1, self.Inner.Min.Y+stage),
+				image.Pt(plot.Min.X+1, self.Inner.Min.Y+stage),
 			)
 			stage++
 		}
-		if self.LabelUpperLimit != "" {
+		// legend from the top of the lines
+		for i := len(self.Series) - 1; i >= 0; i-- {
+			if self.Inner.Min.Y+stage >= plot.Max.Y {
+				break
+			}
+			series := self.Series[i]
 			buf.SetString(
-				self.LabelUpperLimit,
-				NewStyle(self.LimitColor),
-				image.Pt(self.Inner.Min.X+2, self.Inner.Min.Y+stage),
+				"─ "+series.Name+": "+series.Label,
+				NewStyle(series.Color),
+				image.Pt(plot.Min.X+2, self.Inner.Min.Y+stage),
 			)
 			stage++
 		}
-		if self.LabelData != "" {
+	}
+}
+
+// drawAxes puts the ticks on the left of the plot, and the time markers under it.
+func (self *Graph) drawAxes(buf *Buffer, plot image.Rectangle, upper float64) {
+	style := NewStyle(self.AxisColor)
+	if self.FormatValue != nil && upper > 0 {
+		for _, tick := range yTicks(upper) {
+			y := plot.Max.Y - self.calcHeight(plot, upper, tick) - 1
+			label := self.FormatValue(tick)
 			buf.SetString(
-				self.LabelData,
-				NewStyle(self.DataColor),
-				image.Pt(self.Inner.Min.X+2, self.Inner.Min.Y+stage),
+				label+"┤",
+				style,
+				image.Pt(plot.Min.X-len(label)-1, y),
 			)
 		}
 	}
+	if self.Interval > 0 {
+		y := plot.Max.Y
+		buf.SetString(strings.Repeat("─", plot.Dx()), style, image.Pt(plot.Min.X, y))
+		// the latest sample is on the right edge, and the label is on the left of the marker
+		for x := 0; x < plot.Dx(); x += timeMarkerStep {
+			label := formatAgo(time.Duration(x)*self.Interval) + "┴"
+			pos := plot.Max.X - x - len([]rune(label))
+			if pos < plot.Min.X {
+				break
+			}
+			buf.SetString(label, style, image.Pt(pos, y))
+		}
+	}
+}
+
+// formatAgo formats the duration like -1m30s, or now.
+func formatAgo(d time.Duration) string {
+	if d == 0 {
+		return "now"
+	}
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return "-" + s
 }
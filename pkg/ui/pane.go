@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"image"
+	"strings"
+
+	. "github.com/gizak/termui/v3"
+)
+
+// Pane is a TextField with the border, which hides what is drawn under it.
+// The text longer than the pane can be scrolled, and is drawn as it is,
+// without parsing styles in it, since it may hold e.g. labels of the objects.
+type Pane struct {
+	*TextField
+
+	// the first line to draw
+	Offset int
+}
+
+func NewPane() *Pane {
+	return &Pane{
+		TextField: NewTextField(),
+	}
+}
+
+func (self *Pane) ScrollUp() {
+	if self.Offset > 0 {
+		self.Offset--
+	}
+}
+
+func (self *Pane) ScrollDown() {
+	if self.Offset < strings.Count(self.Text, "\n") {
+		self.Offset++
+	}
+}
+
+func (self *Pane) Draw(buf *Buffer) {
+	buf.Fill(NewCell(' ', NewStyle(ColorClear)), self.GetRect())
+	self.Block.Draw(buf)
+
+	cells := RunesToStyledCells([]rune(self.Text), self.TextStyle)
+	rows := SplitCells(cells, '\n')
+	if self.Offset < len(rows) {
+		rows = rows[self.Offset:]
+	}
+	for y, row := range rows {
+		if y+self.Inner.Min.Y >= self.Inner.Max.Y {
+			break
+		}
+		row = TrimCells(row, self.Inner.Dx())
+		for _, cx := range BuildCellWithXArray(row) {
+			x, cell := cx.X, cx.Cell
+			buf.SetCell(cell, image.Pt(x, y).Add(self.Inner.Min))
+		}
+	}
+}
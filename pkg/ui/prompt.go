@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"image"
+
+	. "github.com/gizak/termui/v3"
+)
+
+// Prompt is a single line input on a TextField.
+// The input is drawn as it is, without parsing styles in it.
+type Prompt struct {
+	*TextField
+
+	Label        string
+	Input        string
+	Message      string
+	MessageStyle Style
+}
+
+func NewPrompt() *Prompt {
+	return &Prompt{
+		TextField: NewTextField(),
+	}
+}
+
+func (self *Prompt) Reset(label, input string) {
+	self.Label = label
+	self.Input = input
+	self.Message = ""
+}
+
+func (self *Prompt) Insert(s string) {
+	self.Input += s
+}
+
+func (self *Prompt) Backspace() {
+	runes := []rune(self.Input)
+	if len(runes) > 0 {
+		self.Input = string(runes[:len(runes)-1])
+	}
+}
+
+func (self *Prompt) Draw(buf *Buffer) {
+	// hide the rows of the table under the prompt
+	buf.Fill(NewCell(' ', NewStyle(ColorClear)), self.GetRect())
+	self.Block.Draw(buf)
+
+	if self.Inner.Dy() < 1 {
+		return
+	}
+	line := TrimString(self.Label+self.Input+"_", self.Inner.Dx())
+	buf.SetString(line, self.TextStyle, self.Inner.Min)
+	if self.Message != "" {
+		x := len([]rune(line)) + 2
+		if x < self.Inner.Dx() {
+			buf.SetString(
+				TrimString(self.Message, self.Inner.Dx()-x),
+				self.MessageStyle,
+				image.Pt(self.Inner.Min.X+x, self.Inner.Min.Y),
+			)
+		}
+	}
+}
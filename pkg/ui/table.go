@@ -10,13 +10,22 @@ import (
 type Table struct {
 	*Block
 
-	Header       []string
+	Header []string
+	// widths of the columns, where 0 hides the column
 	ColumnWidths []int
 	Rows         [][]string
 	Cursor       bool
 	CursorColor  Color
+	TextColor    Color
 	topRow       int
 
+	// colors of rows and cells over the default, which are
+	// indexed as Rows, and ColorClear keeps the default.
+	RowColors  []Color
+	CellColors [][]Color
+	// modifiers added to cells, indexed as Rows
+	CellModifiers [][]Modifier
+
 	SelectedRow int
 }
 
@@ -24,6 +33,7 @@ func NewTable() *Table {
 	return &Table{
 		Block:       NewBlock(),
 		Cursor:      true,
+		TextColor:   Theme.Default.Fg,
 		topRow:      0,
 		SelectedRow: 0,
 	}
@@ -34,6 +44,9 @@ func (self *Table) Reset(title string, header []string, width []int) {
 	self.Header = header
 	self.ColumnWidths = width
 	self.Rows = [][]string{}
+	self.RowColors = nil
+	self.CellColors = nil
+	self.CellModifiers = nil
 	self.topRow = 0
 	self.SelectedRow = 0
 }
@@ -52,9 +65,12 @@ func (self *Table) Draw(buf *Buffer) {
 
 		// describe a header
 		for i, h := range self.Header {
+			if self.ColumnWidths[i] == 0 {
+				continue
+			}
 			buf.SetString(
 				h,
-				NewStyle(Theme.Default.Fg, ColorClear, ModifierBold),
+				NewStyle(self.TextColor, ColorClear, ModifierBold),
 				image.Pt(self.Inner.Min.X+columnPositions[i], self.Inner.Min.Y),
 			)
 		}
@@ -70,7 +86,11 @@ func (self *Table) Draw(buf *Buffer) {
 			row := self.Rows[idx]
 			// move y+1 for a header
 			y := self.Inner.Min.Y + 1 + idx - self.topRow
-			style := NewStyle(Theme.Default.Fg)
+			style := NewStyle(self.TextColor)
+			if idx < len(self.RowColors) && self.RowColors[idx] != ColorClear {
+				style.Fg = self.RowColors[idx]
+			}
+			selected := self.Cursor && idx == self.SelectedRow
 			if self.Cursor {
 				if idx == self.SelectedRow {
 					style.Fg = self.CursorColor
@@ -84,10 +104,21 @@ func (self *Table) Draw(buf *Buffer) {
 				}
 			}
 			for i, width := range self.ColumnWidths {
+				if width == 0 {
+					continue
+				}
 				r := TrimString(row[i], width)
+				cellStyle := style
+				// the cursor takes over the colors, so that the selected row is seen
+				if !selected && idx < len(self.CellColors) && i < len(self.CellColors[idx]) && self.CellColors[idx][i] != ColorClear {
+					cellStyle.Fg = self.CellColors[idx][i]
+				}
+				if idx < len(self.CellModifiers) && i < len(self.CellModifiers[idx]) {
+					cellStyle.Modifier |= self.CellModifiers[idx][i]
+				}
 				buf.SetString(
 					r,
-					style,
+					cellStyle,
 					image.Pt(self.Inner.Min.X+columnPositions[i], y),
 				)
 			}
@@ -0,0 +1,97 @@
+package util
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// units to format the values of resources
+const (
+	// to fit the values, e.g. 250m, 1.5 cores and 512Ki, 1.2Gi
+	AutoUnits = "auto"
+	// millicores and MiB, which are easy to compare and to parse
+	FixedUnits = "fixed"
+)
+
+var (
+	Units = []string{AutoUnits, FixedUnits}
+
+	// formatter of the tables and the graphs, see SetUnits
+	formatter = Formatter{Units: AutoUnits}
+
+	// binary suffixes of bytes from the smallest
+	byteSuffixes = []string{"", "Ki", "Mi", "Gi", "Ti"}
+)
+
+// Formatter formats the values of resources in the units.
+type Formatter struct {
+	Units string
+}
+
+// NewFormatter returns the formatter in the units by its name, ignoring case.
+func NewFormatter(name string) (Formatter, error) {
+	for _, u := range Units {
+		if strings.EqualFold(u, name) {
+			return Formatter{Units: u}, nil
+		}
+	}
+	return Formatter{}, errors.Errorf("Unknown units: %v", name)
+}
+
+// SetUnits formats the values of resources in the units by its name, ignoring case.
+func SetUnits(name string) error {
+	f, err := NewFormatter(name)
+	if err != nil {
+		return err
+	}
+	formatter = f
+	return nil
+}
+
+// FormatResourceValue formats the value in the units, see SetUnits and Formatter.FormatResourceValue.
+func FormatResourceValue(name corev1.ResourceName, value float64) string {
+	return formatter.FormatResourceValue(name, value)
+}
+
+// FormatResourceValue formats the value of the resource as GetResourceValue returns it.
+func (f Formatter) FormatResourceValue(name corev1.ResourceName, value float64) string {
+	switch {
+	case name == corev1.ResourceCPU:
+		if f.Units == FixedUnits || value < 999.5 {
+			return formatFloat(value) + "m"
+		}
+		return formatFloat(value / 1000)
+	case isBytes(name):
+		if f.Units == FixedUnits {
+			return formatFloat(value) + "Mi"
+		}
+		value *= 1024 * 1024
+		i := 0
+		for ; i < len(byteSuffixes)-1 && value >= 1023.5; i++ {
+			value /= 1024
+		}
+		return formatFloat(value) + byteSuffixes[i]
+	default:
+		return formatFloat(value)
+	}
+}
+
+// formatFloat keeps 3 significant digits for small values, and drops the trailing zeros.
+func formatFloat(v float64) string {
+	prec := 0
+	switch abs := math.Abs(v); {
+	case abs < 10:
+		prec = 2
+	case abs < 100:
+		prec = 1
+	}
+	s := strconv.FormatFloat(v, 'f', prec, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
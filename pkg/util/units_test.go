@@ -0,0 +1,94 @@
+package util
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestFormatResourceValue(t *testing.T) {
+	const (
+		ki = 1. / 1024
+		gi = 1024.
+	)
+	tests := []struct {
+		name  corev1.ResourceName
+		value float64
+		auto  string
+		fixed string
+	}{
+		{name: corev1.ResourceCPU, value: 0, auto: "0m", fixed: "0m"},
+		{name: corev1.ResourceCPU, value: 0.5, auto: "0.5m", fixed: "0.5m"},
+		{name: corev1.ResourceCPU, value: 250, auto: "250m", fixed: "250m"},
+		{name: corev1.ResourceCPU, value: 999.4, auto: "999m", fixed: "999m"},
+		{name: corev1.ResourceCPU, value: 999.5, auto: "1", fixed: "1000m"},
+		{name: corev1.ResourceCPU, value: 1000, auto: "1", fixed: "1000m"},
+		{name: corev1.ResourceCPU, value: 1500, auto: "1.5", fixed: "1500m"},
+		{name: corev1.ResourceCPU, value: 64000, auto: "64", fixed: "64000m"},
+		{name: corev1.ResourceMemory, value: 0, auto: "0", fixed: "0Mi"},
+		{name: corev1.ResourceMemory, value: 100 * ki / 1024, auto: "100", fixed: "0Mi"},
+		{name: corev1.ResourceMemory, value: 512 * ki, auto: "512Ki", fixed: "0.5Mi"},
+		{name: corev1.ResourceMemory, value: 1023.4 * ki, auto: "1023Ki", fixed: "1Mi"},
+		{name: corev1.ResourceMemory, value: 1023.9 * ki, auto: "1Mi", fixed: "1Mi"},
+		{name: corev1.ResourceMemory, value: 1.25 * gi, auto: "1.25Gi", fixed: "1280Mi"},
+		{name: corev1.ResourceMemory, value: 2048 * gi, auto: "2Ti", fixed: "2097152Mi"},
+		// the largest suffix takes the rest
+		{name: corev1.ResourceMemory, value: 2048 * 1024 * gi, auto: "2048Ti", fixed: "2147483648Mi"},
+		{name: corev1.ResourceEphemeralStorage, value: 10 * gi, auto: "10Gi", fixed: "10240Mi"},
+		{name: "hugepages-2Mi", value: 2, auto: "2Mi", fixed: "2Mi"},
+		{name: "nvidia.com/gpu", value: 2, auto: "2", fixed: "2"},
+		{name: "nvidia.com/gpu", value: 0, auto: "0", fixed: "0"},
+	}
+	for _, tt := range tests {
+		if got := (Formatter{Units: AutoUnits}).FormatResourceValue(tt.name, tt.value); got != tt.auto {
+			t.Errorf("auto %v %v: got %v, want %v", tt.name, tt.value, got, tt.auto)
+		}
+		if got := (Formatter{Units: FixedUnits}).FormatResourceValue(tt.name, tt.value); got != tt.fixed {
+			t.Errorf("fixed %v %v: got %v, want %v", tt.name, tt.value, got, tt.fixed)
+		}
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want string
+	}{
+		{v: 0, want: "0"},
+		{v: 0.001, want: "0"},
+		{v: 0.005, want: "0.01"},
+		{v: 1.5, want: "1.5"},
+		{v: 9.999, want: "10"},
+		{v: 12.34, want: "12.3"},
+		{v: 99.96, want: "100"},
+		{v: 123.4, want: "123"},
+		{v: 1000, want: "1000"},
+		{v: -1.5, want: "-1.5"},
+	}
+	for _, tt := range tests {
+		if got := formatFloat(tt.v); got != tt.want {
+			t.Errorf("%v: got %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestNewFormatter(t *testing.T) {
+	tests := []struct {
+		name  string
+		units string
+		err   bool
+	}{
+		{name: "auto", units: AutoUnits},
+		{name: "Fixed", units: FixedUnits},
+		{name: "millicores", err: true},
+	}
+	for _, tt := range tests {
+		f, err := NewFormatter(tt.name)
+		if (err != nil) != tt.err {
+			t.Errorf("%v: got error %v", tt.name, err)
+		}
+		if f.Units != tt.units {
+			t.Errorf("%v: got %v, want %v", tt.name, f.Units, tt.units)
+		}
+	}
+}
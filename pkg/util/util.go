@@ -2,7 +2,9 @@ package util
 
 import (
 	"fmt"
+	"math"
 	"regexp"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -48,9 +50,9 @@ func FindNode(name string, nodes []corev1.Node) *corev1.Node {
 	return nil
 }
 
-func FindPod(name string, pods []corev1.Pod) *corev1.Pod {
+func FindPod(namespace, name string, pods []corev1.Pod) *corev1.Pod {
 	for _, pod := range pods {
-		if name == pod.Name {
+		if namespace == pod.Namespace && name == pod.Name {
 			return &pod
 		}
 	}
@@ -66,35 +68,70 @@ func FindContainer(name string, containers []corev1.Container) *corev1.Container
 	return nil
 }
 
+// IsExtraResourceName reports whether pods request the resource other than cpu and memory,
+// i.e. ephemeral-storage, hugepages-* and extended resources like nvidia.com/gpu.
+func IsExtraResourceName(name corev1.ResourceName) bool {
+	switch {
+	case name == corev1.ResourceEphemeralStorage:
+		return true
+	case strings.HasPrefix(string(name), corev1.ResourceHugePagesPrefix):
+		return true
+	// extended resources are out of kubernetes.io, and not quotas like requests.nvidia.com/gpu
+	case strings.Contains(string(name), "/"):
+		return !strings.Contains(string(name), corev1.ResourceDefaultNamespacePrefix) &&
+			!strings.HasPrefix(string(name), corev1.DefaultResourceRequestsPrefix)
+	}
+	return false
+}
+
+// isBytes reports whether the resource is in bytes, which are shown in MiB.
+func isBytes(name corev1.ResourceName) bool {
+	return name == corev1.ResourceMemory ||
+		name == corev1.ResourceEphemeralStorage ||
+		strings.HasPrefix(string(name), corev1.ResourceHugePagesPrefix)
+}
+
+// GetResourceValue returns cpu in millicores, resources in bytes in MiB and the others as they are.
 func GetResourceValue(lst corev1.ResourceList, typ corev1.ResourceName) float64 {
 	val, ok := lst[typ]
 	switch {
 	case typ == corev1.ResourceCPU && ok:
-		return float64(val.MilliValue())
-	case typ == corev1.ResourceMemory && ok:
-		return float64(val.Value() / (1024 * 1024))
+		return quantityFloat(val) * 1000
+	case isBytes(typ) && ok:
+		return quantityFloat(val) / (1024 * 1024)
+	case IsExtraResourceName(typ) && ok:
+		return quantityFloat(val)
 	}
 	return 0
 }
 
+// GetResourceValueString formats the value in the units, see SetUnits.
 func GetResourceValueString(lst corev1.ResourceList, typ corev1.ResourceName) string {
-	val, ok := lst[typ]
+	if _, ok := lst[typ]; !ok {
+		return "-"
+	}
 	switch {
-	case typ == corev1.ResourceCPU && ok:
-		return fmt.Sprintf("%vm", val.MilliValue())
-	case typ == corev1.ResourceMemory && ok:
-		return fmt.Sprintf("%vMi", val.Value()/(1024*1024))
+	case typ == corev1.ResourceCPU, isBytes(typ), IsExtraResourceName(typ):
+		return FormatResourceValue(typ, GetResourceValue(lst, typ))
 	default:
 		return "-"
 	}
 }
 
+// quantityFloat returns the quantity in the base unit, e.g. cores or bytes.
+func quantityFloat(q resource.Quantity) float64 {
+	if v := q.Value(); v > math.MaxInt64/1000 || v < math.MinInt64/1000 {
+		return float64(v)
+	}
+	return float64(q.MilliValue()) / 1000
+}
+
 func GetResourcePercentage(usage, available resource.Quantity) float64 {
-	return float64(usage.MilliValue()) / float64(available.MilliValue()) * 100
+	return quantityFloat(usage) / quantityFloat(available) * 100
 }
 
 func GetResourcePercentageString(usage, available resource.Quantity) string {
-	return fmt.Sprintf("%v%%", int(float64(usage.MilliValue())/float64(available.MilliValue())*100))
+	return fmt.Sprintf("%v%%", int(GetResourcePercentage(usage, available)))
 }
 
 func IntMax(x, y int) int {
@@ -110,3 +147,11 @@ func IntMin(x, y int) int {
 	}
 	return y
 }
+
+func IntSum(xs ...int) int {
+	var sum int
+	for _, x := range xs {
+		sum += x
+	}
+	return sum
+}
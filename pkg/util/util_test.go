@@ -0,0 +1,83 @@
+package util
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestGetResourcePercentageString(t *testing.T) {
+	tests := []struct {
+		usage     string
+		available string
+		want      string
+	}{
+		{usage: "250m", available: "1", want: "25%"},
+		{usage: "0", available: "4", want: "0%"},
+		{usage: "3", available: "2", want: "150%"},
+		{usage: "1Gi", available: "4Gi", want: "25%"},
+		// too large for milli values of int64
+		{usage: "4Ei", available: "6Ei", want: "66%"},
+		{usage: "1Ti", available: "6Ei", want: "0%"},
+	}
+	for _, tt := range tests {
+		got := GetResourcePercentageString(resource.MustParse(tt.usage), resource.MustParse(tt.available))
+		if got != tt.want {
+			t.Errorf("%v of %v: got %v, want %v", tt.usage, tt.available, got, tt.want)
+		}
+	}
+}
+
+func TestGetResourceValue(t *testing.T) {
+	lst := corev1.ResourceList{
+		corev1.ResourceCPU:              resource.MustParse("1500m"),
+		corev1.ResourceMemory:           resource.MustParse("512Ki"),
+		corev1.ResourceEphemeralStorage: resource.MustParse("6Ei"),
+		"nvidia.com/gpu":                resource.MustParse("2"),
+		corev1.ResourcePods:             resource.MustParse("110"),
+	}
+	tests := []struct {
+		name corev1.ResourceName
+		want float64
+	}{
+		{name: corev1.ResourceCPU, want: 1500},
+		{name: corev1.ResourceMemory, want: 0.5},
+		{name: corev1.ResourceEphemeralStorage, want: 6 * 1024 * 1024 * 1024 * 1024},
+		{name: "nvidia.com/gpu", want: 2},
+		// not a resource on the tables
+		{name: corev1.ResourcePods, want: 0},
+		{name: "hugepages-2Mi", want: 0},
+	}
+	for _, tt := range tests {
+		if got := GetResourceValue(lst, tt.name); got != tt.want {
+			t.Errorf("%v: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsExtraResourceName(t *testing.T) {
+	tests := []struct {
+		name corev1.ResourceName
+		want bool
+	}{
+		{name: corev1.ResourceCPU, want: false},
+		{name: corev1.ResourceMemory, want: false},
+		{name: corev1.ResourcePods, want: false},
+		{name: corev1.ResourceEphemeralStorage, want: true},
+		{name: "hugepages-2Mi", want: true},
+		{name: "hugepages-1Gi", want: true},
+		{name: "nvidia.com/gpu", want: true},
+		{name: "example.com/foo", want: true},
+		// in kubernetes.io, and quotas on the extended resources
+		{name: "kubernetes.io/foo", want: false},
+		{name: "requests.nvidia.com/gpu", want: false},
+		{name: "requests.cpu", want: false},
+		{name: "limits.memory", want: false},
+	}
+	for _, tt := range tests {
+		if got := IsExtraResourceName(tt.name); got != tt.want {
+			t.Errorf("%v: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}